@@ -0,0 +1,292 @@
+package differ
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Apply sets the After of each change onto target, which must be a pointer to the value that was given to Diff as
+// before. It walks structs, maps and lists the same way Diff does, so applying the result of Diff(key, before, after)
+// onto a pointer to before makes it equal to after.
+//
+// Before a value is changed, it's compared with the Before recorded in the change. If they're not the same, Apply
+// stops and returns a *ConflictError. Changes that were applied before the conflict are kept, so apply onto a copy if
+// the target must stay untouched on conflict.
+//
+// Redacted changes can't be applied since their values are masked, and failed changes have no values. Apply returns
+// error when it reaches one.
+func Apply[K comparable](target any, changes ChangeMap[K]) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Pointer || value.IsNil() {
+		return fmt.Errorf("apply: target must be a non-nil pointer, got %T", target)
+	}
+
+	for _, field := range sortedFields(changes) {
+		err := apply(Path{field.Key}, readable(value.Elem()), field)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply applies the change onto target, which must be settable.
+func apply(path Path, target reflect.Value, field *ChangeField) error {
+	if field.Kind == Unchanged || field.Kind == Moved {
+		// Nothing to set, moves are done by the list that contains the item.
+		return nil
+	}
+	if field.Redacted {
+		return fmt.Errorf("apply: %s: cannot apply redacted change", path)
+	}
+	if field.Kind == Failed {
+		return fmt.Errorf("apply: %s: cannot apply failed change: %w", path, field.Err)
+	}
+
+	if len(field.Changes) == 0 {
+		// A struct field with omitempty is added when it's set from its empty value.
+		if field.Kind == Added && isEmpty(target) == false {
+			return &ConflictError{Path: path, Before: nil, Current: interfaceOf(indirect(target))}
+		}
+		if field.Kind != Added {
+			if err := checkBefore(path, target, field.Before); err != nil {
+				return err
+			}
+		}
+		return setValue(path, target, field.After)
+	}
+
+	// Changes within a struct, map or list, the value must exist.
+	switch target.Kind() {
+	case reflect.Pointer:
+		if target.IsNil() {
+			return &ConflictError{Path: path}
+		}
+		return apply(path, readable(target.Elem()), field)
+	case reflect.Interface:
+		if target.IsNil() {
+			return &ConflictError{Path: path}
+		}
+		// The value held by the interface can't be set, change a copy and put it back.
+		value := readable(target.Elem())
+		if err := apply(path, value, field); err != nil {
+			return err
+		}
+		target.Set(value)
+		return nil
+	case reflect.Struct:
+		return applyStruct(path, target, field)
+	case reflect.Map:
+		return applyMap(path, target, field)
+	case reflect.Slice, reflect.Array:
+		return applySlice(path, target, field)
+	}
+	return fmt.Errorf("apply: %s: cannot apply changes to %s", path, target.Type())
+}
+
+func applyStruct(path Path, target reflect.Value, field *ChangeField) error {
+	fields := make(map[any][]int)
+	for _, f := range structFields(target.Type()) {
+		fields[f.name] = f.index
+	}
+
+	for _, child := range sortedFields(field.Changes) {
+		index, ok := fields[child.Key]
+		if ok == false {
+			return fmt.Errorf("apply: %s: %s has no field %v", path, target.Type(), child.Key)
+		}
+		value := fieldByIndex(target, index)
+		if value.IsValid() == false {
+			return &ConflictError{Path: appendPath(path, child.Key)}
+		}
+		if err := apply(appendPath(path, child.Key), value, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyMap(path Path, target reflect.Value, field *ChangeField) error {
+	mapType := target.Type()
+	for _, child := range sortedFields(field.Changes) {
+		childPath := appendPath(path, child.Key)
+		key, err := convertValue(childPath, reflect.ValueOf(child.Key), mapType.Key())
+		if err != nil {
+			return err
+		}
+		current := target.MapIndex(key)
+
+		switch child.Kind {
+		case Added:
+			if current.IsValid() {
+				return &ConflictError{Path: childPath, Before: nil, Current: interfaceOf(indirect(readable(current)))}
+			}
+			value := reflect.New(mapType.Elem()).Elem()
+			if err := setValue(childPath, value, child.After); err != nil {
+				return err
+			}
+			if target.IsNil() {
+				target.Set(reflect.MakeMap(mapType))
+			}
+			target.SetMapIndex(key, value)
+			continue
+		case Removed:
+			if current.IsValid() == false {
+				return &ConflictError{Path: childPath, Before: child.Before, Current: nil}
+			}
+			if err := checkBefore(childPath, current, child.Before); err != nil {
+				return err
+			}
+			target.SetMapIndex(key, reflect.Value{})
+			continue
+		default:
+			if current.IsValid() == false {
+				return &ConflictError{Path: childPath, Before: child.Before, Current: nil}
+			}
+		}
+
+		// Map values can't be set, change a copy and put it back.
+		value := readable(current)
+		if err := apply(childPath, value, child); err != nil {
+			return err
+		}
+		target.SetMapIndex(key, value)
+	}
+	return nil
+}
+
+func applySlice(path Path, target reflect.Value, field *ChangeField) error {
+	if isSliceChanges(field.Changes) == false {
+		return fmt.Errorf("apply: %s: changes of %s must be keyed by SliceIndex", path, target.Type())
+	}
+
+	items := make([]reflect.Value, target.Len())
+	for i := range items {
+		items[i] = target.Index(i)
+	}
+
+	steps, modified := planSlice(field.Changes)
+	for _, child := range field.Changes {
+		if child.Redacted && child.Kind != Moved {
+			return fmt.Errorf("apply: %s: cannot apply redacted change", appendPath(path, pathElement(child)))
+		}
+	}
+	for _, step := range steps {
+		switch step.op {
+		case stepRemove:
+			childPath := appendPath(path, step.index)
+			before := field.Changes[SliceIndex{Before: step.index, After: -1}].Before
+			if step.index >= len(items) {
+				return &ConflictError{Path: childPath, Before: before}
+			}
+			if err := checkBefore(childPath, items[step.index], before); err != nil {
+				return err
+			}
+			items = append(items[:step.index], items[step.index+1:]...)
+		case stepAdd:
+			childPath := appendPath(path, step.index)
+			if step.index > len(items) {
+				return &ConflictError{Path: childPath}
+			}
+			item := reflect.New(target.Type().Elem()).Elem()
+			if err := setValue(childPath, item, step.field.After); err != nil {
+				return err
+			}
+			items = append(items[:step.index], append([]reflect.Value{item}, items[step.index:]...)...)
+		case stepMove:
+			if step.from >= len(items) {
+				return &ConflictError{Path: appendPath(path, step.from)}
+			}
+			item := items[step.from]
+			copy(items[step.index+1:step.from+1], items[step.index:step.from])
+			items[step.index] = item
+		}
+	}
+
+	// Build the new list, then apply changes within the items that are modified.
+	var result reflect.Value
+	if target.Kind() == reflect.Array {
+		if len(items) != target.Len() {
+			return fmt.Errorf("apply: %s: cannot change the length of %s", path, target.Type())
+		}
+		result = reflect.New(target.Type()).Elem()
+	} else {
+		result = reflect.MakeSlice(target.Type(), len(items), len(items))
+	}
+	for i, item := range items {
+		result.Index(i).Set(item)
+	}
+	for _, child := range modified {
+		index := child.Key.(SliceIndex).After
+		if index >= len(items) {
+			return &ConflictError{Path: appendPath(path, index), Before: child.Before}
+		}
+		if err := apply(appendPath(path, index), result.Index(index), child); err != nil {
+			return err
+		}
+	}
+
+	target.Set(result)
+	return nil
+}
+
+// checkBefore returns *ConflictError if the current value is not the same as the recorded before value.
+func checkBefore(path Path, current reflect.Value, before any) error {
+	change, err := diff(&options{}, path, nil, readable(current), readable(reflect.ValueOf(before)))
+	if err != nil {
+		return err
+	}
+	if change != nil {
+		return &ConflictError{Path: path, Before: before, Current: interfaceOf(indirect(readable(current)))}
+	}
+	return nil
+}
+
+// setValue sets value onto target. Since pointers are resolved by Diff, a value is put into a new pointer when the
+// target is a pointer. Nil sets target to its zero value.
+func setValue(path Path, target reflect.Value, value any) error {
+	if value == nil {
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	if target.Kind() == reflect.Pointer && v.Type().AssignableTo(target.Type()) == false {
+		pointer := reflect.New(target.Type().Elem())
+		if err := setValue(path, pointer.Elem(), value); err != nil {
+			return err
+		}
+		target.Set(pointer)
+		return nil
+	}
+
+	converted, err := convertValue(path, v, target.Type())
+	if err != nil {
+		return err
+	}
+	target.Set(converted)
+	return nil
+}
+
+// convertValue returns v as the given type. Values are only converted between types of the same kind, like a named
+// type and its underlying type.
+func convertValue(path Path, v reflect.Value, t reflect.Type) (reflect.Value, error) {
+	if v.IsValid() == false {
+		switch t.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, fmt.Errorf("apply: %s: cannot use nil as %s", path, t)
+	}
+	if v.Type().AssignableTo(t) {
+		return v, nil
+	}
+	if v.Kind() == t.Kind() && v.Type().ConvertibleTo(t) {
+		return v.Convert(t), nil
+	}
+	// Leaves like big.Int are reported as pointers, they're set on a field of the value type by copying the value.
+	if v.Kind() == reflect.Pointer && v.IsNil() == false && v.Type().Elem() == t {
+		return v.Elem(), nil
+	}
+	return reflect.Value{}, fmt.Errorf("apply: %s: cannot use %s as %s", path, v.Type(), t)
+}
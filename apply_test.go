@@ -0,0 +1,132 @@
+package differ
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type testApplyLine struct {
+	SKU string `differ:"key"`
+	Qty *int
+}
+
+type testApplyOrder struct {
+	Status string
+	Tags   []string
+	Lines  []testApplyLine
+	Meta   map[string]any
+	Prices map[int64]float64
+	Sizes  [3]int
+	Owner  *testAddress
+	note   string
+}
+
+func TestApply(t *testing.T) {
+	one, two := 1, 2
+
+	type testRow struct {
+		name   string
+		before func() any
+		after  func() any
+	}
+
+	runRows := func(t *testing.T, rows []*testRow) {
+		for _, r := range rows {
+			t.Run(r.name, func(t *testing.T) {
+				_, changes, err := Diff("order", r.before(), r.after())
+				assert.Nil(t, err)
+
+				target := r.before()
+				err = Apply(&target, changes)
+				assert.Nil(t, err)
+				assert.Equal(t, r.after(), target)
+			})
+		}
+	}
+
+	runRows(t, []*testRow{
+		{
+			name:   "primitive",
+			before: func() any { return 1 },
+			after:  func() any { return 2 },
+		},
+		{
+			name: "struct fields",
+			before: func() any {
+				return &testApplyOrder{Status: "new", note: "a", Owner: &testAddress{Street: "Main"}}
+			},
+			after: func() any {
+				return &testApplyOrder{Status: "paid", note: "b", Owner: &testAddress{Street: "Side", Number: 2}}
+			},
+		},
+		{
+			name:   "pointer set from nil and to nil",
+			before: func() any { return &testApplyOrder{Owner: &testAddress{Street: "Main"}} },
+			after:  func() any { return &testApplyOrder{Lines: []testApplyLine{{"a", &one}}} },
+		},
+		{
+			name: "maps",
+			before: func() any {
+				return testApplyOrder{
+					Meta:   map[string]any{"a": 1, "b": map[string]any{"c": "d"}, "gone": true},
+					Prices: map[int64]float64{1: 1.5},
+				}
+			},
+			after: func() any {
+				return testApplyOrder{
+					Meta:   map[string]any{"a": 2, "b": map[string]any{"c": "e"}, "new": "x"},
+					Prices: map[int64]float64{1: 2.5, 2: 3},
+				}
+			},
+		},
+		{
+			name:   "lists",
+			before: func() any { return testApplyOrder{Tags: []string{"a", "b", "c", "d"}, Sizes: [3]int{1, 2, 3}} },
+			after:  func() any { return testApplyOrder{Tags: []string{"x", "a", "c", "y"}, Sizes: [3]int{1, 5, 3}} },
+		},
+		{
+			name:   "omitempty fields",
+			before: func() any { return testTagged{Email: "a", Nickname: "a"} },
+			after:  func() any { return testTagged{Email: "b", Manager: &testAddress{Street: "Main"}} },
+		},
+		{
+			name: "lists by key",
+			before: func() any {
+				return testApplyOrder{Lines: []testApplyLine{{"a", &one}, {"b", &one}, {"c", nil}, {"d", &one}}}
+			},
+			after: func() any {
+				return testApplyOrder{Lines: []testApplyLine{{"d", &one}, {"b", &two}, {"a", &one}, {"e", nil}}}
+			},
+		},
+	})
+}
+
+func TestApply_Conflict(t *testing.T) {
+	_, changes, err := Diff("order",
+		testApplyOrder{Status: "new", Meta: map[string]any{"a": 1}},
+		testApplyOrder{Status: "paid", Meta: map[string]any{"a": 1, "b": 2}},
+	)
+	assert.Nil(t, err)
+
+	target := testApplyOrder{Status: "cancelled"}
+	err = Apply(&target, changes)
+	var conflict *ConflictError
+	assert.True(t, errors.As(err, &conflict))
+	assert.Equal(t, Path{"order", "Status"}, conflict.Path)
+	assert.Equal(t, "new", conflict.Before)
+	assert.Equal(t, "cancelled", conflict.Current)
+	assert.Equal(t, "apply: conflict at order.Status: expected new, found cancelled", err.Error())
+
+	target = testApplyOrder{Status: "new", Meta: map[string]any{"b": 3}}
+	err = Apply(&target, changes)
+	assert.True(t, errors.As(err, &conflict))
+	assert.Equal(t, Path{"order", "Meta", "b"}, conflict.Path)
+	assert.Equal(t, 3, conflict.Current)
+}
+
+func TestApply_NotPointer(t *testing.T) {
+	_, changes, err := Diff("int", 1, 2)
+	assert.Nil(t, err)
+	assert.NotNil(t, Apply(1, changes))
+}
@@ -1,5 +1,10 @@
 package differ
 
+import (
+	"reflect"
+	"strconv"
+)
+
 /*
 
 Diff(parentKey, map, map) -->
@@ -65,21 +70,138 @@ What's the algorithm on the diff, how does it recognize inserted items?
 
 */
 
+// ChangeKind is the type of change recorded in a ChangeField.
+type ChangeKind int
+
+const (
+	// Unchanged means the value is the same, it's only reported when unchanged fields are requested.
+	Unchanged ChangeKind = iota
+	// Added means the item is new in a list or map.
+	Added
+	// Removed means the item no longer exists in a list or map.
+	Removed
+	// Modified means the value has changed. If it's a struct, map or list, the changes are in ChangeField.Changes.
+	Modified
+	// Moved means the list item is the same, but its position relative to the other items has changed.
+	Moved
+	// TypeChanged means the value has a different type, which happens with interface fields and maps of any.
+	TypeChanged
+	// Failed means the value couldn't be diffed, the error is in ChangeField.Err. It's only reported when errors are
+	// collected with WithCollectErrors.
+	Failed
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Unchanged:
+		return "unchanged"
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	case Moved:
+		return "moved"
+	case TypeChanged:
+		return "type changed"
+	case Failed:
+		return "failed"
+	}
+	return "ChangeKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// SliceIndex is the key of a list item in ChangeField.Changes. Before is the index of the item in the before list and
+// After is its index in the after list. Deleted items have After set to -1, and new items have Before set to -1.
+type SliceIndex struct {
+	Before int
+	After  int
+}
+
+// NilKind is the kind of nil of a value, recorded in ChangeField.BeforeNil and ChangeField.AfterNil.
+type NilKind int
+
+const (
+	// NotNil means the value is not nil.
+	NotNil NilKind = iota
+	// UntypedNil means there's no value at all: a nil interface, or nil given to Diff.
+	UntypedNil
+	// NilPointer means the value is a typed nil pointer, like a nil *Address.
+	NilPointer
+	// NilSlice means the value is a nil slice, as opposed to an empty slice.
+	NilSlice
+	// NilMap means the value is a nil map, as opposed to an empty map.
+	NilMap
+)
+
+func (k NilKind) String() string {
+	switch k {
+	case NotNil:
+		return "not nil"
+	case UntypedNil:
+		return "untyped nil"
+	case NilPointer:
+		return "nil pointer"
+	case NilSlice:
+		return "nil slice"
+	case NilMap:
+		return "nil map"
+	}
+	return "NilKind(" + strconv.Itoa(int(k)) + ")"
+}
+
 type ChangeMap[T comparable] map[T]*ChangeField
 
 // ChangeField represents a field. The Before and After could be Go primitive types (int, string, float, bool, etc.),
 // but it could also be representing non-primitive types:
-//   - Structs are represented with ChangeMap.
-//   - Maps are represented with ChangeMap.
-//   - Lists are represented with ChangeMap, with the index changed to string with strconv.Itoa.
+//   - Structs are represented with ChangeMap, keyed by field name.
+//   - Maps are represented with ChangeMap, keyed by the map key.
+//   - Lists are represented with ChangeMap, keyed by SliceIndex.
+//
+// Before and After hold the values with their original Go types, with pointers and interfaces resolved. When the
+// field is a struct, map or list that has changes within it, the changes are put in Changes and Before/After are left
+// empty.
+//
+// Kind tells how the field has changed. When IsNew is true, it means this is a new item in a list or map, the Kind is
+// then Added. Kind Removed means the item no longer exists in the list or map, while a field that is set to nil is
+// Modified with nil After.
+//
+// When Redacted is true, Before and After hold masks instead of the values, and the changes within the value are not
+// reported. When Cycle is true, the value refers back to a value that is being diffed higher up the path, like a child
+// referring to its parent. It's not looked into again, its changes are reported where it was first reached. By
+// default a cycle leaves no trace in the ChangeMap, it's only reported when unchanged fields are requested, or as an
+// error with WithCycleError.
+//
+// Err is the error of a value that couldn't be diffed, when Kind is Failed.
 //
-// When IsNew is true, it means this is a new item in ChangeList or ChangeMap.
+// JSONName is the name of a struct field in the JSON encoding of the struct, used by JSONPatch. It's only set when it
+// differs from Key, like a field named with `differ:"name=..."`, and it's "-" for a field that is not part of the JSON
+// encoding, like an unexported field. JSONEmpty is set on a struct field that is Added or Removed because of
+// `differ:"omitempty"` while its json tag doesn't have omitempty, so the JSON encoding still has the field with its
+// empty value. JSONPatch replaces it with the empty value instead of removing it.
+//
+// BeforeType and AfterType are set when Kind is TypeChanged, which happens with interface fields and maps of any.
+//
+// BeforeNil and AfterNil tell whether each side is nil and which kind of nil it is, since a nil pointer and a missing
+// value both have nil Before or After. A nil slice or map is kept as is in Before or After.
 type ChangeField struct {
 	Key       any
+	Kind      ChangeKind
 	IsNew     bool
 	IsChanged bool
-	Changes   ChangeMap[]
+	Redacted  bool
+	Cycle     bool
+	Changes   ChangeMap[any]
+
+	Before     any
+	After      any
+	BeforeType reflect.Type
+	AfterType  reflect.Type
+	BeforeNil  NilKind
+	AfterNil   NilKind
+
+	Err error
 
-	Before any
-	After  any
+	JSONName  string
+	JSONEmpty bool
 }
@@ -1,683 +1,1177 @@
 package differ
 
 import (
-	"encoding/json"
+	"bytes"
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 )
 
-//	- Hasn't passed json.Marshal yet, could be struct, could be map[string]int.
-// After passing json.Marshal:
-//	- All maps are map[any]any?
-//	- All slices are []any?
-
 // Diff will return the list of changes. If the given values are primitive, then the returned ChangeMap will only
-// consist of 1 field with the given key. When struct, map, or list are given, the given key will be ignored and
-// ChangeMap will contain only keys that has changed within the given struct, map, or list.
+// consist of 1 field with the given key. When struct, map, or list are given, the returned ChangeMap will contain 1
+// field with the given key, and the fields that has changed within the given struct, map, or list are put in its
+// Changes.
+//
+// Diff walks the values using reflection, so Before and After of each ChangeField hold the values with their
+// original types. Pointers and interfaces are resolved, and unexported fields are compared too. Struct fields are
+// named and skipped according to their differ tag, falling back to their json tag, see structFields for the options.
+// It will return a *DiffError if it encounters a type that cannot be compared, like chan or func.
 //
-// Diff uses json.Marshal internally, it will return error anything in the value cannot be marshalled. This should cover
-// most types where a diff would be useful. Because it uses json.Marshal, it will resolve pointers and handle
-// interfaces.
+// The diff can be configured with options, like WithUnchanged or WithIgnoredPaths.
 func Diff[K comparable](
 	key K,
 	before any,
 	after any,
+	opts ...Option,
 ) (
 	hasChanges bool,
 	changes ChangeMap[K],
 	err error,
 ) {
-	valueBefore := reflect.ValueOf(before)
-	kindBefore := valueBefore.Kind()
-	valueAfter := reflect.ValueOf(after)
-	kindAfter := valueAfter.Kind()
-
-	// If the value is a pointer, then we need to figure out the type of what is being pointed.
-	// Unless if it's nil, then we don't care.
-	// todo: check for truly nil interface value
-	// todo: check for nil interface with concrete type already set, but value is a nil pointer.
-	if kindBefore == reflect.Ptr && valueBefore.IsNil() == false {
-		resolved := reflect.Indirect(valueBefore)
-		kindBefore = resolved.Kind()
-	}
-	if kindAfter == reflect.Ptr && valueAfter.IsNil() == false {
-		resolved := reflect.Indirect(valueAfter)
-		kindAfter = resolved.Kind()
-	}
-
-	// Prepare value if it's a struct/map, convert it to the typical map[string]any.
-	if kindBefore == reflect.Struct || kindBefore == reflect.Map {
-		beforeBytes, err := json.Marshal(before)
-		if err != nil {
-			return false, nil, err
-		}
-		before = make(map[string]any)
-		err = json.Unmarshal(beforeBytes, &before)
-		if err != nil {
-			return false, nil, err
-		}
+	o, err := newOptions(opts)
+	if err != nil {
+		return false, nil, err
 	}
-	if kindAfter == reflect.Struct || kindAfter == reflect.Map {
-		afterBytes, err := json.Marshal(after)
-		if err != nil {
-			return false, nil, err
-		}
-		after = make(map[string]any)
-		err = json.Unmarshal(afterBytes, &after)
-		if err != nil {
-			return false, nil, err
-		}
+	field, err := diff(o, Path{key}, key, readable(reflect.ValueOf(before)), readable(reflect.ValueOf(after)))
+	if err != nil {
+		return false, nil, err
 	}
 
-	// Prepare value if it's a slice, convert it to the typical []any.
-	if kindBefore == reflect.Slice || kindBefore == reflect.Array {
-		beforeBytes, err := json.Marshal(before)
-		if err != nil {
-			return false, nil, err
-		}
-		before = make([]any, 0)
-		err = json.Unmarshal(beforeBytes, &before)
-		if err != nil {
-			return false, nil, err
+	changes = make(ChangeMap[K])
+	if field == nil {
+		return false, changes, nil
+	}
+	changes[key] = field
+	return field.Kind != Unchanged, changes, errors.Join(failures(field)...)
+}
+
+// Differ is implemented by types that diff themselves, for example when their fields are not the best way to
+// describe what changed. Diff is called on the before value with the after value, which is always of the same type.
+// It returns the changes within the value, keyed by struct field name, map key or SliceIndex like the changes
+// returned by Diff, or no changes if the values are the same.
+type Differ interface {
+	Diff(after any) (changes ChangeMap[any], err error)
+}
+
+var differType = reflect.TypeOf((*Differ)(nil)).Elem()
+
+// asDiffer returns the value as Differ if its type, or a pointer to its type, implements Differ.
+func asDiffer(v reflect.Value) (Differ, bool) {
+	if v.Type().Implements(differType) {
+		return v.Interface().(Differ), true
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(differType) {
+		return v.Addr().Interface().(Differ), true
+	}
+	return nil, false
+}
+
+// diff returns the ChangeField for the given key, which is located at path. It returns nil if before and after are
+// the same, or a ChangeField with Kind Unchanged if unchanged fields are requested.
+//
+// Nil values are resolved and compared like empty values, so a nil pointer is the same as a nil interface, and a nil
+// slice or map is the same as an empty one, unless their kind of nil is given to WithNilChanges. The kind of nil of
+// each side is recorded in the ChangeField.
+func diff(
+	o *options,
+	path Path,
+	key any,
+	before reflect.Value,
+	after reflect.Value,
+) (
+	change *ChangeField,
+	err error,
+) {
+	beforeNil := nilKindOf(before)
+	afterNil := nilKindOf(after)
+	change, err = diffValue(o, path, key, before, after)
+	if err != nil && o.collectErrors {
+		return &ChangeField{Key: key, Kind: Failed, IsChanged: true, Err: err}, nil
+	}
+	if err != nil || (beforeNil == NotNil && afterNil == NotNil) {
+		return change, err
+	}
+
+	if isChanged(change) == false && beforeNil != afterNil && (o.nilChanges[beforeNil] || o.nilChanges[afterNil]) {
+		change = &ChangeField{
+			Key:       key,
+			Kind:      Modified,
+			IsNew:     false,
+			IsChanged: true,
+			Before:    interfaceOf(indirect(before)),
+			After:     interfaceOf(indirect(after)),
 		}
 	}
-	if kindAfter == reflect.Slice || kindAfter == reflect.Array {
-		afterBytes, err := json.Marshal(after)
-		if err != nil {
-			return false, nil, err
+	if change != nil {
+		change.BeforeNil = beforeNil
+		change.AfterNil = afterNil
+	}
+	return change, nil
+}
+
+// nilKindOf returns the kind of nil of the value, following pointers and interfaces.
+func nilKindOf(v reflect.Value) NilKind {
+	for {
+		if v.IsValid() == false {
+			return UntypedNil
 		}
-		after = make([]any, 0)
-		err = json.Unmarshal(afterBytes, &after)
-		if err != nil {
-			return false, nil, err
+		switch v.Kind() {
+		case reflect.Interface:
+			if v.IsNil() {
+				return UntypedNil
+			}
+			v = v.Elem()
+		case reflect.Pointer:
+			if v.IsNil() {
+				return NilPointer
+			}
+			v = v.Elem()
+		case reflect.Slice:
+			if v.IsNil() {
+				return NilSlice
+			}
+			return NotNil
+		case reflect.Map:
+			if v.IsNil() {
+				return NilMap
+			}
+			return NotNil
+		default:
+			return NotNil
 		}
 	}
-
-	// At this point, structs & maps are normalized into map[string]any.
-	// Slices and arrays are normalized into []any.
-	// The following diff functions no longer needs to check for other values.
-	return diff(key, before, after)
 }
 
-func diff[K comparable](
-	key K,
-	before any,
-	after any,
+// diffValue is diff without the handling of nil kinds.
+func diffValue(
+	o *options,
+	path Path,
+	key any,
+	before reflect.Value,
+	after reflect.Value,
 ) (
-	hasChanges bool,
-	changes ChangeMap[K],
+	change *ChangeField,
 	err error,
 ) {
-	changes = make(ChangeMap[K])
+	before = indirect(before)
+	after = indirect(after)
+
+	// A redacted value is diffed as usual, then the values and changes within it are masked.
+	if o.redacts(path) {
+		inner := *o
+		inner.redactedPaths = nil
+		// Errors are recorded on the redacted value itself, since the changes within it are dropped.
+		inner.collectErrors = false
+		change, err := diff(&inner, path, key, before, after)
+		if err != nil || change == nil {
+			return change, err
+		}
+		return o.redact(change, interfaceOf(before), interfaceOf(after)), nil
+	}
 
-	if before == nil {
-		if after == nil {
+	if before.IsValid() == false {
+		if after.IsValid() == false {
 			// Both values are nil.
-			return false, changes, nil
+			return unchanged(o, key, before, after), nil
 		}
 
-		// Otherwise the change is a new value.
-		changes[key] = &ChangeField{
+		// Otherwise the value is set from nil.
+		return &ChangeField{
 			Key:       key,
-			IsNew:     true,
+			Kind:      Modified,
+			IsNew:     false,
 			IsChanged: true,
-			Before:    before,
-			After:     after,
-		}
-		return true, changes, nil
+			Before:    nil,
+			After:     after.Interface(),
+		}, nil
 	}
 
 	// This catches when "before" is not nil but "after" is nil.
-	if after == nil {
-		changes[key] = &ChangeField{
+	if after.IsValid() == false {
+		return &ChangeField{
 			Key:       key,
+			Kind:      Modified,
 			IsNew:     false,
 			IsChanged: true,
-			Before:    before,
-			After:     after,
-		}
-		return true, changes, nil
+			Before:    before.Interface(),
+			After:     nil,
+		}, nil
 	}
 
-	// In most cases, the majority of values in after json.Marshal should be primitive values.
-	if valBefore, ok := before.(int); ok {
-		valAfter, ok := after.(int)
-		// Check for different type or different value.
-		if ok == false || valBefore != valAfter {
-			changes[key] = &ChangeField{
-				Key:       key,
-				IsNew:     false,
-				IsChanged: true,
-				Before:    before,
-				After:     after,
-			}
-			return true, changes, nil
+	// Values of different types are always a change, we don't look inside them.
+	if before.Type() != after.Type() {
+		if o.strictTypes {
+			return nil, newDiffError(path, before, after, ErrNotTheSameType)
 		}
+		return modified(key, before, after), nil
+	}
 
-		// Otherwise value is the same.
-		return false, changes, nil
+	// Types that are compared by a comparator, by their own Diff method or as a known leaf are not looked into.
+	if equal, ok := o.comparators[before.Type()]; ok {
+		if equal(before.Interface(), after.Interface()) {
+			return unchanged(o, key, before, after), nil
+		}
+		return modified(key, before, after), nil
+	}
+	if l, ok := leaves[before.Type()]; ok {
+		return diffLeaf(o, key, l, before, after), nil
+	}
+	if differ, ok := asDiffer(before); ok {
+		changes, err := differ.Diff(after.Interface())
+		if err != nil {
+			return nil, newDiffError(path, before, after, err)
+		}
+		return container(o, key, changes), nil
 	}
 
-	if valBefore, ok := before.(bool); ok {
-		valAfter, ok := after.(bool)
-		// Check for different type or different value.
-		if ok == false || valBefore != valAfter {
-			changes[key] = &ChangeField{
-				Key:       key,
-				IsNew:     false,
-				IsChanged: true,
-				Before:    before,
-				After:     after,
+	var equal bool
+	switch before.Kind() {
+	case reflect.Bool:
+		equal = before.Bool() == after.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		equal = before.Int() == after.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		equal = before.Uint() == after.Uint()
+	case reflect.Float32, reflect.Float64:
+		equal = o.equalFloat(before.Float(), after.Float())
+	case reflect.Complex64, reflect.Complex128:
+		b, a := before.Complex(), after.Complex()
+		equal = o.equalFloat(real(b), real(a)) && o.equalFloat(imag(b), imag(a))
+	case reflect.String:
+		equal = before.String() == after.String()
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		if o.atMaxDepth(path) {
+			change, err := diffWhole(o, path, key, before, after)
+			if err == nil && o.maxDepthError && isChanged(change) {
+				return nil, newDiffError(path, before, after, ErrMaxDepth)
 			}
-			return true, changes, nil
+			return change, err
 		}
 
-		// Otherwise value is the same.
-		return false, changes, nil
-	}
-
-	if valBefore, ok := before.(string); ok {
-		valAfter, ok := after.(string)
-		// Check for different type or different value.
-		if ok == false || valBefore != valAfter {
-			changes[key] = &ChangeField{
-				Key:       key,
-				IsNew:     false,
-				IsChanged: true,
-				Before:    before,
-				After:     after,
+		// Values that are already being diffed higher up the path are not looked into again, so that structures
+		// referring back to themselves (like a child referring to its parent) don't recurse forever. Their changes are
+		// reported where they were first reached.
+		visit, ok := visitOf(before, after)
+		if ok && o.visiting[visit] {
+			if o.cycleError {
+				return nil, newDiffError(path, before, after, ErrCycle)
+			}
+			if o.unchanged == false {
+				return nil, nil
 			}
-			return true, changes, nil
+			return &ChangeField{Key: key, Kind: Unchanged, Cycle: true}, nil
+		}
+		if ok {
+			if o.visiting == nil {
+				o.visiting = make(map[visited]bool)
+			}
+			o.visiting[visit] = true
+			defer delete(o.visiting, visit)
 		}
 
-		// Otherwise value is the same.
-		return false, changes, nil
+		switch before.Kind() {
+		case reflect.Struct:
+			return diffStruct(o, path, key, before, after)
+		case reflect.Map:
+			return diffMap(o, path, key, before, after)
+		}
+		return diffSlice(o, path, key, before, after)
+	default:
+		// If we reach this part it means it's a type we don't support, like chan, func, uintptr, or unsafe.Pointer.
+		return nil, newDiffError(path, before, after, ErrUnsupportedKind)
 	}
 
-	if valBefore, ok := before.(float64); ok {
-		valAfter, ok := after.(float64)
-		// Check for different type or different value.
-		if ok == false || valBefore != valAfter {
-			changes[key] = &ChangeField{
-				Key:       key,
-				IsNew:     false,
-				IsChanged: true,
-				Before:    before,
-				After:     after,
-			}
-			return true, changes, nil
-		}
+	if equal {
+		return unchanged(o, key, before, after), nil
+	}
+	return modified(key, before, after), nil
+}
 
-		// Otherwise value is the same.
-		return false, changes, nil
+// modified returns a ChangeField for a value that exists on both sides but has changed.
+func modified(key any, before reflect.Value, after reflect.Value) *ChangeField {
+	change := &ChangeField{
+		Key:       key,
+		Kind:      Modified,
+		IsNew:     false,
+		IsChanged: true,
+		Before:    before.Interface(),
+		After:     after.Interface(),
 	}
+	if before.Type() != after.Type() {
+		change.Kind = TypeChanged
+		change.BeforeType = before.Type()
+		change.AfterType = after.Type()
+	}
+	return change
+}
+
+// unchanged returns a ChangeField for a value that is the same on both sides if unchanged fields are requested,
+// otherwise it returns nil.
+func unchanged(o *options, key any, before reflect.Value, after reflect.Value) *ChangeField {
+	if o.unchanged == false {
+		return nil
+	}
+	return &ChangeField{
+		Key:    key,
+		Kind:   Unchanged,
+		Before: interfaceOf(before),
+		After:  interfaceOf(after),
+	}
+}
 
-	if valBefore, ok := before.(int64); ok {
-		valAfter, ok := after.(int64)
-		// Check for different type or different value.
-		if ok == false || valBefore != valAfter {
-			changes[key] = &ChangeField{
+// container returns the ChangeField of a struct, map or list with the given changes. It returns nil if there are no
+// changes, or a ChangeField with Kind Unchanged if all the changes are unchanged fields.
+func container(o *options, key any, changes ChangeMap[any]) *ChangeField {
+	for _, child := range changes {
+		if child.Kind != Unchanged {
+			return &ChangeField{
 				Key:       key,
-				IsNew:     false,
+				Kind:      Modified,
 				IsChanged: true,
-				Before:    before,
-				After:     after,
+				Changes:   changes,
 			}
-			return true, changes, nil
 		}
+	}
+	if o.unchanged == false {
+		return nil
+	}
+	return &ChangeField{
+		Key:     key,
+		Kind:    Unchanged,
+		Changes: changes,
+	}
+}
 
-		// Otherwise value is the same.
-		return false, changes, nil
+// isChanged returns true if the ChangeField returned by diff is a change.
+func isChanged(change *ChangeField) bool {
+	return change != nil && change.Kind != Unchanged
+}
+
+// visited is a pair of values that is being diffed, identified by their address.
+type visited struct {
+	before uintptr
+	after  uintptr
+	typ    reflect.Type
+	len    int
+}
+
+// visitOf returns the addresses of the given struct, map, list or array values. Values that are not addressable can't
+// be part of a cycle, since a cycle is only made through pointers, maps or slices.
+func visitOf(before reflect.Value, after reflect.Value) (visited, bool) {
+	switch before.Kind() {
+	case reflect.Map, reflect.Slice:
+		if before.Pointer() == 0 || after.Pointer() == 0 {
+			return visited{}, false
+		}
+		return visited{before: before.Pointer(), after: after.Pointer(), typ: before.Type(), len: before.Len()}, true
+	}
+	if before.CanAddr() == false || after.CanAddr() == false {
+		return visited{}, false
 	}
+	return visited{before: before.UnsafeAddr(), after: after.UnsafeAddr(), typ: before.Type()}, true
+}
 
-	if valBefore, ok := before.(uint64); ok {
-		valAfter, ok := after.(uint64)
-		// Check for different type or different value.
-		if ok == false || valBefore != valAfter {
-			changes[key] = &ChangeField{
-				Key:       key,
+// redactEntry masks the values of a new, removed or moved list item or map key if its path is redacted.
+func redactEntry(o *options, path Path, change *ChangeField) *ChangeField {
+	if o.redacts(path) == false {
+		return change
+	}
+	return o.redact(change, change.Before, change.After)
+}
+
+// diffWhole compares 2 structs, maps or lists without reporting the changes within them. If they're different, the
+// values are reported as a whole. The values are walked from their own path, so that ignored paths and errors below
+// them are matched and reported from the root.
+func diffWhole(
+	o *options,
+	path Path,
+	key any,
+	before reflect.Value,
+	after reflect.Value,
+) (
+	change *ChangeField,
+	err error,
+) {
+	whole := *o
+	whole.maxDepth = 0
+	whole.unchanged = false
+	whole.collectErrors = false
+	change, err = diff(&whole, path, key, before, after)
+	if err != nil {
+		return nil, err
+	}
+	if change == nil {
+		return unchanged(o, key, before, after), nil
+	}
+	return modified(key, before, after), nil
+}
+
+// diffStruct compares 2 structs of the same type field by field.
+func diffStruct(
+	o *options,
+	path Path,
+	key any,
+	before reflect.Value,
+	after reflect.Value,
+) (
+	change *ChangeField,
+	err error,
+) {
+	changes := make(ChangeMap[any])
+	for _, field := range structFields(before.Type()) {
+		fieldPath := appendPath(path, field.name)
+		if o.ignores(fieldPath) {
+			continue
+		}
+
+		valueBefore := fieldByIndex(before, field.index)
+		valueAfter := fieldByIndex(after, field.index)
+		fieldOptions := o
+		if field.redact {
+			// A field redacted by tag is redacted like a field redacted by path.
+			redacted := *o
+			redacted.redactedPaths = append(o.redactedPaths[:len(o.redactedPaths):len(o.redactedPaths)],
+				literalPattern(fieldPath[1:]))
+			fieldOptions = &redacted
+		}
+		child, err := diff(fieldOptions, fieldPath, field.name, valueBefore, valueAfter)
+		if err != nil {
+			return nil, err
+		}
+		if child == nil {
+			continue
+		}
+
+		// With omitempty, the empty value means the field is absent.
+		if isChanged(child) && field.omitEmpty && isEmpty(valueBefore) {
+			child = &ChangeField{
+				Key:       field.name,
+				Kind:      Added,
+				IsNew:     true,
+				IsChanged: true,
+				Before:    nil,
+				After:     interfaceOf(indirect(valueAfter)),
+				BeforeNil: nilKindOf(valueBefore),
+				AfterNil:  nilKindOf(valueAfter),
+				JSONEmpty: field.jsonEmpty,
+			}
+		} else if isChanged(child) && field.omitEmpty && isEmpty(valueAfter) {
+			child = &ChangeField{
+				Key:       field.name,
+				Kind:      Removed,
 				IsNew:     false,
 				IsChanged: true,
-				Before:    before,
-				After:     after,
+				Before:    interfaceOf(indirect(valueBefore)),
+				After:     nil,
+				BeforeNil: nilKindOf(valueBefore),
+				AfterNil:  nilKindOf(valueAfter),
+				JSONEmpty: field.jsonEmpty,
 			}
-			return true, changes, nil
 		}
-
-		// Otherwise value is the same.
-		return false, changes, nil
+		if field.redact && child.Redacted == false && child.Kind != Failed {
+			// The empty value replaced the redacted change above.
+			child = o.redact(child, interfaceOf(indirect(valueBefore)), interfaceOf(indirect(valueAfter)))
+		}
+		child.JSONName = field.jsonName
+		changes[field.name] = child
 	}
 
-	// Next on the list, check for maps.
-	if valBefore, ok := before.(map[string]any); ok {
-		valAfter, ok := after.(map[string]any)
-		// The after value is of different type.
-		if ok == false {
-			changes[key] = &ChangeField{
-				Key:       key,
+	return container(o, key, changes), nil
+}
+
+// diffMap compares 2 maps of the same type. Keys that only exist in before are recorded as removed, keys that only
+// exist in after are recorded as new, and keys that exist in both are diffed recursively.
+func diffMap(
+	o *options,
+	path Path,
+	key any,
+	before reflect.Value,
+	after reflect.Value,
+) (
+	change *ChangeField,
+	err error,
+) {
+	changes := make(ChangeMap[any])
+
+	// First check all keys on before.
+	iter := before.MapRange()
+	for iter.Next() {
+		k := iter.Key()
+		keyPath := appendPath(path, k.Interface())
+		if o.ignores(keyPath) {
+			continue
+		}
+
+		valueBefore := readable(iter.Value())
+		valueAfter := after.MapIndex(k)
+		if valueAfter.IsValid() == false {
+			changes[k.Interface()] = redactEntry(o, keyPath, &ChangeField{
+				Key:       k.Interface(),
+				Kind:      Removed,
 				IsNew:     false,
 				IsChanged: true,
-				Before:    before,
-				After:     after,
-			}
-			return true, changes, nil
+				Before:    interfaceOf(indirect(valueBefore)),
+				After:     nil,
+				BeforeNil: nilKindOf(valueBefore),
+			})
+			continue
 		}
 
-		// Otherwise, we need to check the diff.
-		var mapChanges = ChangeMap[string]{}
-		hasChanges, mapChanges, err = diffMap(valBefore, valAfter)
+		// Otherwise we must diff the before and after value of this key.
+		child, err := diff(o, keyPath, k.Interface(), valueBefore, readable(valueAfter))
 		if err != nil {
-			return false, nil, err
+			return nil, err
 		}
-		if hasChanges {
-			changes[key] = &ChangeField{}
+		if child != nil {
+			changes[k.Interface()] = child
 		}
+	}
 
-		return false, changes, nil
+	// Next check keys that only exist on after, keys that exist on both are already checked.
+	iter = after.MapRange()
+	for iter.Next() {
+		k := iter.Key()
+		if before.MapIndex(k).IsValid() || o.ignores(appendPath(path, k.Interface())) {
+			continue
+		}
+		changes[k.Interface()] = redactEntry(o, appendPath(path, k.Interface()), &ChangeField{
+			Key:       k.Interface(),
+			Kind:      Added,
+			IsNew:     true,
+			IsChanged: true,
+			Before:    nil,
+			After:     interfaceOf(indirect(readable(iter.Value()))),
+			AfterNil:  nilKindOf(iter.Value()),
+		})
 	}
 
-	// Next on the list, check for slices.
-	// todo
+	return container(o, key, changes), nil
+}
 
-	if valBefore, ok := before.(int32); ok {
-		valAfter, ok := after.(int32)
-		// Check for different type or different value.
-		if ok == false || valBefore != valAfter {
-			changes[key] = &ChangeField{
-				Key:       key,
-				IsNew:     false,
-				IsChanged: true,
-				Before:    before,
-				After:     after,
-			}
-			return true, changes, nil
+// DiffMap compares 2 maps and returns the changes of each key, keyed by the map key with its own type. Keys can be of
+// any comparable type, like int64, a named string type, or a struct.
+//
+// Only the changes of the keys are reported. A change of the map as a whole, like a nil map that becomes empty with
+// WithNilChanges(NilMap), or a comparator given for the map type, has no key to be reported under, so it's not
+// reported and hasChanges is false. Use Diff to see those.
+func DiffMap[M ~map[K]V, K comparable, V any](
+	before M,
+	after M,
+	opts ...Option,
+) (
+	hasChanges bool,
+	changes ChangeMap[K],
+	err error,
+) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return false, nil, err
+	}
+	field, err := diff(o, Path{nil}, nil, readable(reflect.ValueOf(before)), readable(reflect.ValueOf(after)))
+	var errs []error
+	if err != nil {
+		errs = []error{err}
+	} else if field != nil {
+		errs = failures(field)
+	}
+	for _, err := range errs {
+		// Paths are relative to the map, there's no key given.
+		var diffErr *DiffError
+		if errors.As(err, &diffErr) {
+			diffErr.Path = diffErr.Path[1:]
 		}
+	}
+	if err != nil {
+		return false, nil, err
+	}
 
-		// Otherwise value is the same.
+	changes = make(ChangeMap[K])
+	if field == nil {
 		return false, changes, nil
 	}
+	for k, child := range field.Changes {
+		// A nil key of an interface key type is kept as the zero K.
+		key, _ := k.(K)
+		changes[key] = child
+		hasChanges = hasChanges || isChanged(child)
+	}
+	return hasChanges, changes, errors.Join(errs...)
+}
 
-	if valBefore, ok := before.(float32); ok {
-		valAfter, ok := after.(float32)
-		// Check for different type or different value.
-		if ok == false || valBefore != valAfter {
-			changes[key] = &ChangeField{
-				Key:       key,
-				IsNew:     false,
-				IsChanged: true,
-				Before:    before,
-				After:     after,
-			}
-			return true, changes, nil
+// failures returns the errors of the failed values within the change, sorted by path.
+func failures(change *ChangeField) []error {
+	if change.Kind == Failed {
+		return []error{change.Err}
+	}
+	var errs []error
+	for _, child := range sortedFields(change.Changes) {
+		errs = append(errs, failures(child)...)
+	}
+	return errs
+}
+
+// DiffSlice is like Diff, but it returns error if before or after is not a slice or an array.
+func DiffSlice[K comparable, T any](
+	key K,
+	before T,
+	after T,
+	opts ...Option,
+) (
+	hasChanges bool,
+	changes ChangeMap[K],
+	err error,
+) {
+	valueBefore := indirect(readable(reflect.ValueOf(before)))
+	valueAfter := indirect(readable(reflect.ValueOf(after)))
+	for _, value := range []reflect.Value{valueBefore, valueAfter} {
+		if value.IsValid() && value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+			return false, nil, newDiffError(Path{key}, valueBefore, valueAfter, ErrUnsupportedKind)
 		}
+	}
 
-		// Otherwise value is the same.
-		return false, changes, nil
+	return Diff(key, before, after, opts...)
+}
+
+// diffSlice compares 2 slices or arrays of the same type. By default, items are matched using the shortest edit
+// script between the 2 lists, so inserting an item at the top is reported as 1 new item instead of every index being
+// changed. Items that are deleted and inserted at the same spot are paired and diffed recursively as modified items.
+// Lists of structs with a key field, or lists that have an identity for every item with WithSliceKey, are matched
+// by identity instead.
+//
+// The changes are keyed by SliceIndex, which holds the index of the item in the before and after list.
+func diffSlice(
+	o *options,
+	path Path,
+	key any,
+	before reflect.Value,
+	after reflect.Value,
+) (
+	change *ChangeField,
+	err error,
+) {
+	// Byte slices and byte arrays are usually opaque data (hashes, encoded content, UUIDs), so they're compared as a
+	// whole.
+	if before.Type().Elem().Kind() == reflect.Uint8 {
+		var equal bool
+		if before.Kind() == reflect.Array {
+			equal = before.Interface() == after.Interface()
+		} else {
+			equal = bytes.Equal(before.Bytes(), after.Bytes())
+		}
+		if equal {
+			return unchanged(o, key, before, after), nil
+		}
+		return modified(key, before, after), nil
 	}
 
-	if valBefore, ok := before.(uint); ok {
-		valAfter, ok := after.(uint)
-		// Check for different type or different value.
-		if ok == false || valBefore != valAfter {
-			changes[key] = &ChangeField{
-				Key:       key,
-				IsNew:     false,
-				IsChanged: true,
-				Before:    before,
-				After:     after,
+	// Lists of structs that have a key field are matched by identity instead of position.
+	if index, ok := keyField(before.Type().Elem()); ok {
+		return diffSliceByKey(o, path, key, before, after, func(elem reflect.Value) any {
+			elem = indirect(elem)
+			if elem.IsValid() == false {
+				return nil
 			}
-			return true, changes, nil
-		}
+			return interfaceOf(indirect(fieldByIndex(elem, index)))
+		})
+	}
+	if o.sliceKey != nil && hasSliceKeys(o, before) && hasSliceKeys(o, after) {
+		return diffSliceByKey(o, path, key, before, after, func(elem reflect.Value) any {
+			return o.sliceKey(elem.Interface())
+		})
+	}
 
-		// Otherwise value is the same.
-		return false, changes, nil
+	// Each pair of items is recorded with the given before and after index, when it's changed or unchanged fields
+	// are requested.
+	changes := make(ChangeMap[any])
+	pair := func(i int, j int) error {
+		index := SliceIndex{Before: i, After: j}
+		itemPath := appendPath(path, j)
+		if o.ignores(itemPath) {
+			return nil
+		}
+		child, err := diff(o, itemPath, index, readable(before.Index(i)), readable(after.Index(j)))
+		if err != nil {
+			return err
+		}
+		if child != nil {
+			changes[index] = child
+		}
+		return nil
+	}
+	removed := func(i int) {
+		index := SliceIndex{Before: i, After: -1}
+		if o.ignores(appendPath(path, i)) {
+			return
+		}
+		changes[index] = redactEntry(o, appendPath(path, i), &ChangeField{
+			Key:       index,
+			Kind:      Removed,
+			IsNew:     false,
+			IsChanged: true,
+			Before:    interfaceOf(indirect(readable(before.Index(i)))),
+			After:     nil,
+			BeforeNil: nilKindOf(before.Index(i)),
+		})
+	}
+	added := func(j int) {
+		index := SliceIndex{Before: -1, After: j}
+		if o.ignores(appendPath(path, j)) {
+			return
+		}
+		changes[index] = redactEntry(o, appendPath(path, j), &ChangeField{
+			Key:       index,
+			Kind:      Added,
+			IsNew:     true,
+			IsChanged: true,
+			Before:    nil,
+			After:     interfaceOf(indirect(readable(after.Index(j)))),
+			AfterNil:  nilKindOf(after.Index(j)),
+		})
 	}
 
-	if valBefore, ok := before.(uint32); ok {
-		valAfter, ok := after.(uint32)
-		// Check for different type or different value.
-		if ok == false || valBefore != valAfter {
-			changes[key] = &ChangeField{
-				Key:       key,
-				IsNew:     false,
-				IsChanged: true,
-				Before:    before,
-				After:     after,
+	if o.sliceMatching == MatchIndex {
+		for i := 0; i < before.Len() || i < after.Len(); i++ {
+			switch {
+			case i >= after.Len():
+				removed(i)
+			case i >= before.Len():
+				added(i)
+			default:
+				if err := pair(i, i); err != nil {
+					return nil, err
+				}
 			}
-			return true, changes, nil
 		}
+		return container(o, key, changes), nil
+	}
 
-		// Otherwise value is the same.
-		return false, changes, nil
+	// The edit script only asks whether 2 items are equal. Items of different types, too deep or cyclic items are not
+	// errors there, those errors are only for items that end up paired.
+	equalOptions := *o
+	equalOptions.unchanged = false
+	equalOptions.strictTypes = false
+	equalOptions.maxDepthError = false
+	equalOptions.cycleError = false
+	edits, err := myers(before.Len(), after.Len(), func(i int, j int) (bool, error) {
+		child, err := diff(&equalOptions, appendPath(path, j), nil, readable(before.Index(i)), readable(after.Index(j)))
+		return child == nil, err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if valBefore, ok := before.(int16); ok {
-		valAfter, ok := after.(int16)
-		// Check for different type or different value.
-		if ok == false || valBefore != valAfter {
-			changes[key] = &ChangeField{
-				Key:       key,
-				IsNew:     false,
-				IsChanged: true,
-				Before:    before,
-				After:     after,
+	var deleted, inserted []int
+	// flush records the deleted and inserted items between 2 matches.
+	flush := func() error {
+		for i := 0; i < len(deleted) && i < len(inserted); i++ {
+			if err := pair(deleted[i], inserted[i]); err != nil {
+				return err
 			}
-			return true, changes, nil
 		}
-
-		// Otherwise value is the same.
-		return false, changes, nil
+		for i := len(inserted); i < len(deleted); i++ {
+			removed(deleted[i])
+		}
+		for i := len(deleted); i < len(inserted); i++ {
+			added(inserted[i])
+		}
+		deleted, inserted = deleted[:0], inserted[:0]
+		return nil
 	}
 
-	if valBefore, ok := before.(int8); ok {
-		valAfter, ok := after.(int8)
-		// Check for different type or different value.
-		if ok == false || valBefore != valAfter {
-			changes[key] = &ChangeField{
-				Key:       key,
-				IsNew:     false,
-				IsChanged: true,
-				Before:    before,
-				After:     after,
+	for _, e := range edits {
+		switch e.op {
+		case editDelete:
+			deleted = append(deleted, e.before)
+		case editInsert:
+			inserted = append(inserted, e.after)
+		case editMatch:
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			if o.unchanged {
+				if err := pair(e.before, e.after); err != nil {
+					return nil, err
+				}
 			}
-			return true, changes, nil
 		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
 
-		// Otherwise value is the same.
-		return false, changes, nil
+	return container(o, key, changes), nil
+}
+
+// hasSliceKeys returns true if the key function of WithSliceKey returns an identity for every item of the list.
+func hasSliceKeys(o *options, list reflect.Value) bool {
+	for i := 0; i < list.Len(); i++ {
+		if o.sliceKey(readable(list.Index(i)).Interface()) == nil {
+			return false
+		}
 	}
+	return true
+}
 
-	if valBefore, ok := before.(uint32); ok {
-		valAfter, ok := after.(uint32)
-		// Check for different type or different value.
-		if ok == false || valBefore != valAfter {
-			changes[key] = &ChangeField{
-				Key:       key,
-				IsNew:     false,
-				IsChanged: true,
-				Before:    before,
-				After:     after,
+// diffSliceByKey compares 2 slices or arrays of the same type, matching the items by the identity returned by keyOf.
+// Items with a nil or zero identity, like new items that don't have a database ID yet, are never matched, they're
+// recorded as removed or new. Items that keep their identity but are reordered relative to the other items are
+// recorded as Moved.
+func diffSliceByKey(
+	o *options,
+	path Path,
+	key any,
+	before reflect.Value,
+	after reflect.Value,
+	keyOf func(elem reflect.Value) any,
+) (
+	change *ChangeField,
+	err error,
+) {
+	identities := func(list reflect.Value) ([]any, map[any]int, error) {
+		ids := make([]any, list.Len())
+		indexes := make(map[any]int, list.Len())
+		for i := range ids {
+			id := keyOf(readable(list.Index(i)))
+			if id == nil || reflect.ValueOf(id).IsZero() {
+				continue
+			}
+			if reflect.TypeOf(id).Comparable() == false {
+				return nil, nil, newDiffError(path, before, after, fmt.Errorf("list key must be comparable, got %T", id))
 			}
-			return true, changes, nil
+			if _, ok := indexes[id]; ok {
+				return nil, nil, newDiffError(path, before, after, fmt.Errorf("duplicate list key: %v", id))
+			}
+			ids[i] = id
+			indexes[id] = i
 		}
+		return ids, indexes, nil
+	}
+	idsBefore, indexesBefore, err := identities(before)
+	if err != nil {
+		return nil, err
+	}
+	idsAfter, indexesAfter, err := identities(after)
+	if err != nil {
+		return nil, err
+	}
 
-		// Otherwise value is the same.
-		return false, changes, nil
+	// Items that exist on both sides, in before and after order.
+	var matchedBefore, matchedAfter []int
+	for i, id := range idsBefore {
+		if _, ok := indexesAfter[id]; ok {
+			matchedBefore = append(matchedBefore, i)
+		}
+	}
+	for j, id := range idsAfter {
+		if _, ok := indexesBefore[id]; ok {
+			matchedAfter = append(matchedAfter, j)
+		}
 	}
 
-	if valBefore, ok := before.(uint16); ok {
-		valAfter, ok := after.(uint16)
-		// Check for different type or different value.
-		if ok == false || valBefore != valAfter {
-			changes[key] = &ChangeField{
-				Key:       key,
+	// The longest sequence of items that kept their relative order are not moved, the rest are.
+	edits, err := myers(len(matchedBefore), len(matchedAfter), func(i int, j int) (bool, error) {
+		return idsBefore[matchedBefore[i]] == idsAfter[matchedAfter[j]], nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	moved := make(map[int]bool)
+	for _, e := range edits {
+		if e.op == editDelete {
+			moved[matchedBefore[e.before]] = true
+		}
+	}
+
+	changes := make(ChangeMap[any])
+	for i, id := range idsBefore {
+		j, ok := indexesAfter[id]
+		if ok == false {
+			if o.ignores(appendPath(path, i)) {
+				continue
+			}
+			index := SliceIndex{Before: i, After: -1}
+			changes[index] = redactEntry(o, appendPath(path, i), &ChangeField{
+				Key:       index,
+				Kind:      Removed,
 				IsNew:     false,
 				IsChanged: true,
-				Before:    before,
-				After:     after,
-			}
-			return true, changes, nil
+				Before:    interfaceOf(indirect(readable(before.Index(i)))),
+				After:     nil,
+				BeforeNil: nilKindOf(before.Index(i)),
+			})
+			continue
 		}
 
-		// Otherwise value is the same.
-		return false, changes, nil
-	}
-
-	if valBefore, ok := before.(uint8); ok {
-		valAfter, ok := after.(uint8)
-		// Check for different type or different value.
-		if ok == false || valBefore != valAfter {
-			changes[key] = &ChangeField{
-				Key:       key,
+		itemPath := appendPath(path, j)
+		if o.ignores(itemPath) {
+			continue
+		}
+		index := SliceIndex{Before: i, After: j}
+		child, err := diff(o, itemPath, index, readable(before.Index(i)), readable(after.Index(j)))
+		if err != nil {
+			return nil, err
+		}
+		if moved[i] && isChanged(child) == false {
+			child = redactEntry(o, itemPath, &ChangeField{
+				Key:       index,
+				Kind:      Moved,
 				IsNew:     false,
 				IsChanged: true,
-				Before:    before,
-				After:     after,
+				Before:    interfaceOf(indirect(readable(before.Index(i)))),
+				After:     interfaceOf(indirect(readable(after.Index(j)))),
+			})
+		}
+		if child != nil {
+			changes[index] = child
+		}
+	}
+	for j, id := range idsAfter {
+		if _, ok := indexesBefore[id]; ok || o.ignores(appendPath(path, j)) {
+			continue
+		}
+		index := SliceIndex{Before: -1, After: j}
+		changes[index] = redactEntry(o, appendPath(path, j), &ChangeField{
+			Key:       index,
+			Kind:      Added,
+			IsNew:     true,
+			IsChanged: true,
+			Before:    nil,
+			After:     interfaceOf(indirect(readable(after.Index(j)))),
+			AfterNil:  nilKindOf(after.Index(j)),
+		})
+	}
+
+	return container(o, key, changes), nil
+}
+
+// structField is a field that takes part in the diff.
+type structField struct {
+	name      string
+	jsonName  string
+	index     []int
+	omitEmpty bool
+	redact    bool
+	// jsonEmpty is true when the field is absent for the differ tag when it's empty, but the JSON encoding still has
+	// it because the json tag doesn't have omitempty.
+	jsonEmpty bool
+}
+
+// structFields returns the fields of the given struct type that should be diffed, in declaration order. Like
+// json.Marshal, fields of embedded structs are promoted unless the embedded field is given a name in its tag.
+//
+// Names and exclusions come from the differ tag, and fall back to the json tag so that existing structs keep their
+// json field names:
+//   - `differ:"-"` skips the field.
+//   - `differ:"name=Display Name"` names the field in the ChangeMap.
+//   - `differ:"omitempty"` treats the empty value of the field as absent, so a field that is set from its empty value
+//     is Added, and a field that is set to its empty value is Removed.
+//   - `differ:"redact"` masks the values of the field, see WithRedactedPaths.
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+	var skipped [][]int
+	for _, field := range reflect.VisibleFields(t) {
+		if hasPrefix(field.Index, skipped) {
+			continue
+		}
+
+		opts := parseTag(field.Tag.Get("differ"))
+		if opts.skip {
+			skipped = append(skipped, field.Index)
+			continue
+		}
+
+		name := opts.name
+		if name == "" {
+			var ok bool
+			name, ok = jsonFieldName(field)
+			if ok == false {
+				skipped = append(skipped, field.Index)
+				continue
 			}
-			return true, changes, nil
 		}
 
-		// Otherwise value is the same.
-		return false, changes, nil
+		if field.Anonymous {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			// Fields of the embedded struct are promoted and listed on their own.
+			if embedded.Kind() == reflect.Struct && name == field.Name {
+				continue
+			}
+			// Otherwise the embedded value is treated like any other field.
+			skipped = append(skipped, field.Index)
+		}
+
+		// The name in the JSON encoding is kept for JSONPatch when the differ tag gives the field another name.
+		// Unexported fields are diffed, but they're not part of the JSON encoding.
+		jsonName, ok := jsonFieldName(field)
+		if ok == false || field.IsExported() == false {
+			jsonName = "-"
+		}
+		if jsonName == name {
+			jsonName = ""
+		}
+
+		fields = append(fields, structField{
+			name:      name,
+			jsonName:  jsonName,
+			index:     field.Index,
+			omitEmpty: opts.omitEmpty,
+			redact:    opts.redact,
+			jsonEmpty: opts.omitEmpty && jsonName != "-" && jsonOmitEmpty(field) == false,
+		})
 	}
 
-	// If we reach this part it means it's a type we don't expect.
-	return false, nil, fmt.Errorf("diff: unexpected type: %T %T", before, after)
+	return fields
 }
 
-func diffMap(
-	before map[string]any,
-	after map[string]any,
-) (
-	hasChanges bool,
-	changes ChangeMap[string],
-	err error,
-) {
+// jsonFieldName returns the name of the field in the JSON encoding of its struct. It returns false if the json tag
+// leaves the field out.
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		return field.Name, true
+	}
+	return name, true
+}
 
-	//checked := make(map[any]struct{})
-	changes = make(ChangeMap[any])
+// jsonOmitEmpty returns true if the json tag of the field has the omitempty option.
+func jsonOmitEmpty(field reflect.StructField) bool {
+	_, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "omitempty" {
+			return true
+		}
+	}
+	return false
+}
 
-	// First check all keys on before.
-	//for k, beforeVal := range beforeMap {
-	//	afterVal, ok := afterMap[k]
-	//	if ok == false {
-	//		changes[k] = &ChangeField{
-	//			Key:       k,
-	//			IsChanged: true,
-	//			Before:    beforeVal,
-	//			After:     afterVal,
-	//		}
-	//		continue
-	//	}
-	//
-	//	// Otherwise we must diff the before and after value of this key.
-	//	// The value of the key could have different types.
-	//	// Because we might be comparing between some "any" shit.
-	//	// Is there a way to do this without resorting to using reflection again?
-	//	//
-	//
-	//}
-	panic("not implemented yet")
+// tagOptions are the options given in the differ struct tag, separated by comma.
+type tagOptions struct {
+	skip      bool
+	name      string
+	omitEmpty bool
+	key       bool
+	redact    bool
 }
 
-func DiffSlice[K comparable, T any](
-	key K,
-	before T,
-	after T,
-	allFields bool,
-) (
-	hasChanges bool,
-	changes ChangeMap[K],
-	err error,
-) {
-	beforeBytes, err := json.Marshal(before)
-	if err != nil {
-		return false, nil, err
+func parseTag(tag string) tagOptions {
+	var opts tagOptions
+	if tag == "-" {
+		opts.skip = true
+		return opts
 	}
-	beforeSlice := make([]any, 0)
-	err = json.Unmarshal(beforeBytes, &beforeSlice)
-	if err != nil {
-		return false, nil, err
+	for _, opt := range strings.Split(tag, ",") {
+		opt = strings.TrimSpace(opt)
+		switch {
+		case strings.HasPrefix(opt, "name="):
+			opts.name = strings.TrimPrefix(opt, "name=")
+		case opt == "omitempty":
+			opts.omitEmpty = true
+		case opt == "key":
+			opts.key = true
+		case opt == "redact":
+			opts.redact = true
+		}
 	}
+	return opts
+}
 
-	afterBytes, err := json.Marshal(after)
-	if err != nil {
-		return false, nil, err
+// keyField returns the index of the field tagged with `differ:"key"` when the given list item type is a struct, or a
+// pointer to a struct.
+func keyField(t reflect.Type) (index []int, ok bool) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
 	}
-	afterSlice := make([]any, 0)
-	err = json.Unmarshal(afterBytes, &afterSlice)
-	if err != nil {
-		return false, nil, err
+	if t.Kind() != reflect.Struct {
+		return nil, false
+	}
+	for _, field := range reflect.VisibleFields(t) {
+		if parseTag(field.Tag.Get("differ")).key {
+			return field.Index, true
+		}
 	}
+	return nil, false
+}
 
-	panic("not implemented yet")
-}
-
-// todo: switch to implementation by reflection.
-//func DiffSlice[K comparable](
-//	key K,
-//	before []any,
-//	after []any,
-//	allFields bool,
-//) (
-//	hasChanges bool,
-//	changes ChangeMap[K],
-//	err error,
-//) {
-//	kind := before.Kind()
-//	kindAf := after.Kind()
-//	if kind != kindAf {
-//		return false, nil, fmt.Errorf(
-//			"diff: on key %v, kind %s is not %s",
-//			key,
-//			kind.String(),
-//			kindAf.String(),
-//		)
-//	}
-//
-//	switch kind {
-//	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-//		vBf := before.Int()
-//		vAf := after.Int()
-//		return DiffComparable(key, vBf, vAf, allFields)
-//	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-//		vBf := before.Uint()
-//		vAf := after.Uint()
-//		return DiffComparable(key, vBf, vAf, allFields)
-//	case reflect.Float32, reflect.Float64:
-//		vBf := before.Float()
-//		vAf := after.Float()
-//		return DiffComparable(key, vBf, vAf, allFields)
-//	case reflect.String:
-//		vBf := before.String()
-//		vAf := after.String()
-//		return DiffComparable(key, vBf, vAf, allFields)
-//	case reflect.Bool:
-//		vBf := before.Bool()
-//		vAf := after.Bool()
-//		return DiffComparable(key, vBf, vAf, allFields)
-//	case reflect.Struct:
-//		return DiffStruct(key, before, allFields)
-//	case reflect.Array, reflect.Slice:
-//	case reflect.Map:
-//		vBf := before.Convert(reflect.TypeOf(make(map[any]any)))
-//		vAf := after.Convert(reflect.TypeOf(make(map[any]any)))
-//		return diffMap(key, &vBf, &vAf)
-//	case reflect.Chan:
-//	case reflect.Func:
-//	case reflect.Complex64:
-//	case reflect.Complex128:
-//
-//	case reflect.Interface:
-//	case reflect.Pointer:
-//
-//	case reflect.Invalid:
-//		return false, nil, fmt.Errorf("diff: unsupported type: Invalid")
-//	case reflect.UnsafePointer:
-//		return false, nil, fmt.Errorf("diff: unsupported type: UnsafePointer")
-//	case reflect.Uintptr:
-//		// Uintptr is used in unsafe black magic. Not supported.
-//		return false, nil, fmt.Errorf("diff: unsupported type: Uintptr")
-//	}
-//
-//	fmt.Println(before, after)
-//
-//	return
-//}
-//
-//func DiffComparable[K comparable, T comparable](
-//	key K,
-//	before T,
-//	after T,
-//	allFields bool,
-//) (
-//	hasChanges bool,
-//	changes ChangeMap[K],
-//	err error,
-//) {
-//	changes = make(ChangeMap[K])
-//	if before != after {
-//		changes[key] = &ChangeField{
-//			Key:       key,
-//			IsChanged: true,
-//			Before:    before,
-//			After:     after,
-//		}
-//		return true, changes, nil
-//	}
-//
-//	if allFields {
-//		changes[key] = &ChangeField{
-//			Key:    key,
-//			Before: before,
-//			After:  after,
-//		}
-//	}
-//	return false, changes, nil
-//}
-//
-//func DiffStruct[K comparable](key K, bf any, af any) (hasChanges bool, changes ChangeMap[K], err error) {
-//	panic("not implemented yet")
-//}
-//
-//func diffMap[K comparable](
-//	key K,
-//	before *reflect.Value,
-//	after *reflect.Value,
-//) (
-//	hasChanges bool,
-//	changes ChangeMap[K],
-//	err error,
-//) {
-//	//checked := make(map[any]struct{})
-//	//changes = make(ChangeMap[K])
-//	//
-//	//// First check keys that exist before.
-//	//bfIter := before.MapRange()
-//	//for bfIter.Next() {
-//	//	k := bfIter.Key()
-//	//	valBf := bfIter.Value()
-//	//	checked[k] = struct{}{}
-//	//
-//	//	// If key doesn't exist in after, add to ChangeMap.
-//	//	valAf := after.MapIndex(k)
-//	//	if valAf.IsZero() {
-//	//		changes[k.Interface()] = &ChangeField{
-//	//			Key:    k,
-//	//			Before: before.Interface(),
-//	//			After:  nil,
-//	//		}
-//	//		hasChanges = true
-//	//		continue
-//	//	}
-//	//
-//	//	// Otherwise key exist, check if they are the same by diffing them.
-//	//	isChanged, ch, err := Diff[K](k, vBf, vAf, false)
-//	//	if err != nil {
-//	//		return false, nil, err
-//	//	}
-//	//
-//	//	if isChanged {
-//	//		changes[k] = ch[k]
-//	//		hasChanges = true
-//	//		continue
-//	//	}
-//	//}
-//	//
-//	//// Next check keys that exist on after, but skip keys we already checked.
-//	//for k, v := range after {
-//	//	if _, ok := checked[k]; ok {
-//	//		// Skip if already checked.
-//	//		continue
-//	//	}
-//	//
-//	//}
-//	//
-//	//return changes
-//
-//	panic("not implemented yet")
-//}
-//
-//func DiffList[T any](a []T, b []T) string {
-//	panic("not implemented yet")
-//}
+// isEmpty returns true if v is the empty value for omitempty: nil, zero, or a list, map or string of length 0.
+func isEmpty(v reflect.Value) bool {
+	if v.IsValid() == false {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// hasPrefix returns true if index is nested inside one of the given prefixes.
+func hasPrefix(index []int, prefixes [][]int) bool {
+	for _, prefix := range prefixes {
+		if len(index) > len(prefix) && reflect.DeepEqual(index[:len(prefix)], prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldByIndex returns the nested field of the given struct. When the field is promoted through a nil embedded
+// pointer, the returned value is invalid, which diff treats like nil.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 {
+			v = indirect(v)
+			if v.IsValid() == false {
+				return v
+			}
+		}
+		v = readable(v.Field(x))
+	}
+	return v
+}
+
+// interfaceOf returns the value held by v, or nil if v is invalid.
+func interfaceOf(v reflect.Value) any {
+	if v.IsValid() == false {
+		return nil
+	}
+	return v.Interface()
+}
+
+// indirect resolves pointers and interfaces until it reaches a concrete value. Nil pointers and nil interfaces are
+// returned as invalid reflect.Value.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = readable(v.Elem())
+	}
+	return v
+}
+
+// readable returns v as an addressable value that can be read with Interface. Values reached through unexported
+// struct fields cannot normally be read, so they're accessed through their address instead. Values that are not
+// addressable (map values, interface contents) are copied, so that fields of structs inside them are addressable.
+func readable(v reflect.Value) reflect.Value {
+	if v.IsValid() == false {
+		return v
+	}
+	if v.CanAddr() {
+		if v.CanInterface() {
+			return v
+		}
+		return reflect.NewAt(v.Type(), v.Addr().UnsafePointer()).Elem()
+	}
+
+	copied := reflect.New(v.Type()).Elem()
+	copied.Set(v)
+	return copied
+}
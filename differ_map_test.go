@@ -28,3 +28,302 @@ func TestMap(t *testing.T) {
 	fmt.Println(val)
 	fmt.Printf("%T \n", val.(map[string]any)["6"])
 }
+
+func TestMap_Diff(t *testing.T) {
+	type testRow struct {
+		name   string
+		key    string
+		before any
+		after  any
+
+		expectHasChanges bool
+		expectChanges    any
+	}
+
+	runRows := func(t *testing.T, rows []*testRow) {
+		for _, r := range rows {
+			t.Run(r.name, func(t *testing.T) {
+				hasChanges, changes, err := Diff(r.key, r.before, r.after)
+				assert.Nil(t, err)
+				assert.Equal(t, r.expectHasChanges, hasChanges)
+				assert.Equal(t, r.expectChanges, changes)
+			})
+		}
+	}
+
+	runRows(t, []*testRow{
+		{
+			name:             "map equal",
+			key:              "map",
+			before:           map[string]any{"a": 1, "b": map[string]any{"c": "d"}},
+			after:            map[string]any{"a": 1, "b": map[string]any{"c": "d"}},
+			expectHasChanges: false,
+			expectChanges:    ChangeMap[string]{},
+		},
+		{
+			name:             "key is updated",
+			key:              "map",
+			before:           map[string]int{"a": 1, "b": 2},
+			after:            map[string]int{"a": 1, "b": 3},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[string]{
+				"map": {
+					Key:       "map",
+					Kind:      Modified,
+					IsChanged: true,
+					Changes: ChangeMap[any]{
+						"b": {
+							Key:       "b",
+							Kind:      Modified,
+							IsChanged: true,
+							Before:    2,
+							After:     3,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:             "key is added and deleted",
+			key:              "map",
+			before:           map[string]int{"a": 1, "b": 2},
+			after:            map[string]int{"a": 1, "c": 2},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[string]{
+				"map": {
+					Key:       "map",
+					Kind:      Modified,
+					IsChanged: true,
+					Changes: ChangeMap[any]{
+						"b": {
+							Key:       "b",
+							Kind:      Removed,
+							IsChanged: true,
+							Before:    2,
+							After:     nil,
+						},
+						"c": {
+							Key:       "c",
+							Kind:      Added,
+							IsNew:     true,
+							IsChanged: true,
+							Before:    nil,
+							After:     2,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:             "key with nil value is deleted",
+			key:              "map",
+			before:           map[string]any{"a": nil},
+			after:            map[string]any{},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[string]{
+				"map": {
+					Key:       "map",
+					Kind:      Modified,
+					IsChanged: true,
+					Changes: ChangeMap[any]{
+						"a": {
+							Key:       "a",
+							Kind:      Removed,
+							IsChanged: true,
+							Before:    nil,
+							After:     nil,
+							BeforeNil: UntypedNil,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:             "value A and value B swapped",
+			key:              "map",
+			before:           map[string]string{"a": "A", "b": "B"},
+			after:            map[string]string{"a": "B", "b": "A"},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[string]{
+				"map": {
+					Key:       "map",
+					Kind:      Modified,
+					IsChanged: true,
+					Changes: ChangeMap[any]{
+						"a": {
+							Key:       "a",
+							Kind:      Modified,
+							IsChanged: true,
+							Before:    "A",
+							After:     "B",
+						},
+						"b": {
+							Key:       "b",
+							Kind:      Modified,
+							IsChanged: true,
+							Before:    "B",
+							After:     "A",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "nested map changed",
+			key:  "map",
+			before: map[string]any{
+				"3": 3232,
+				"6": map[string]any{"5": 123, "7": "hello"},
+			},
+			after: map[string]any{
+				"3": 3232,
+				"6": map[string]any{"5": 124, "7": "hello"},
+			},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[string]{
+				"map": {
+					Key:       "map",
+					Kind:      Modified,
+					IsChanged: true,
+					Changes: ChangeMap[any]{
+						"6": {
+							Key:       "6",
+							Kind:      Modified,
+							IsChanged: true,
+							Changes: ChangeMap[any]{
+								"5": {
+									Key:       "5",
+									Kind:      Modified,
+									IsChanged: true,
+									Before:    123,
+									After:     124,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:             "map of struct",
+			key:              "map",
+			before:           map[string]testAddress{"home": {Street: "Main"}},
+			after:            map[string]testAddress{"home": {Street: "Side"}},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[string]{
+				"map": {
+					Key:       "map",
+					Kind:      Modified,
+					IsChanged: true,
+					Changes: ChangeMap[any]{
+						"home": {
+							Key:       "home",
+							Kind:      Modified,
+							IsChanged: true,
+							Changes: ChangeMap[any]{
+								"street": {
+									Key:       "street",
+									Kind:      Modified,
+									IsChanged: true,
+									Before:    "Main",
+									After:     "Side",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+type testUserID string
+
+type testRegion struct {
+	Country string
+	City    string
+}
+
+func TestMap_KeyTypes(t *testing.T) {
+	type product struct {
+		Name  string
+		Price int64
+	}
+
+	hasChanges, products, err := DiffMap(
+		map[int64]product{1: {"a", 100}, 2: {"b", 200}},
+		map[int64]product{1: {"a", 150}, 3: {"c", 300}},
+	)
+	assert.Nil(t, err)
+	assert.True(t, hasChanges)
+	assert.Equal(t, ChangeMap[int64]{
+		1: {
+			Key:       int64(1),
+			Kind:      Modified,
+			IsChanged: true,
+			Changes: ChangeMap[any]{
+				"Price": {Key: "Price", Kind: Modified, IsChanged: true, Before: int64(100), After: int64(150)},
+			},
+		},
+		2: {Key: int64(2), Kind: Removed, IsChanged: true, Before: product{"b", 200}},
+		3: {Key: int64(3), Kind: Added, IsNew: true, IsChanged: true, After: product{"c", 300}},
+	}, products)
+
+	hasChanges, roles, err := DiffMap(
+		map[testUserID]string{"u1": "admin", "u2": "viewer"},
+		map[testUserID]string{"u1": "admin", "u2": "editor"},
+	)
+	assert.Nil(t, err)
+	assert.True(t, hasChanges)
+	assert.Equal(t, ChangeMap[testUserID]{
+		"u2": {Key: testUserID("u2"), Kind: Modified, IsChanged: true, Before: "viewer", After: "editor"},
+	}, roles)
+
+	hasChanges, regions, err := DiffMap(
+		map[testRegion]int{{"ID", "Jakarta"}: 1},
+		map[testRegion]int{{"ID", "Jakarta"}: 2},
+	)
+	assert.Nil(t, err)
+	assert.True(t, hasChanges)
+	assert.Equal(t, ChangeMap[testRegion]{
+		{"ID", "Jakarta"}: {Key: testRegion{"ID", "Jakarta"}, Kind: Modified, IsChanged: true, Before: 1, After: 2},
+	}, regions)
+
+	// A nil key of an interface key type.
+	hasChanges, anyKeys, err := DiffMap(map[any]int{nil: 1, "a": 1}, map[any]int{"a": 1})
+	assert.Nil(t, err)
+	assert.True(t, hasChanges)
+	assert.Equal(t, ChangeMap[any]{nil: {Key: nil, Kind: Removed, IsChanged: true, Before: 1}}, anyKeys)
+
+	anyTarget := map[any]int{"a": 1}
+	_, anyChanges, err := Diff("m", anyTarget, map[any]int{nil: 2, "a": 1})
+	assert.Nil(t, err)
+	assert.Nil(t, Apply(&anyTarget, anyChanges))
+	assert.Equal(t, map[any]int{nil: 2, "a": 1}, anyTarget)
+
+	hasChanges, regions, err = DiffMap(map[testRegion]int(nil), map[testRegion]int{})
+	assert.Nil(t, err)
+	assert.False(t, hasChanges)
+	assert.Equal(t, ChangeMap[testRegion]{}, regions)
+
+	// Changes of the map as a whole have no key to be reported under.
+	hasChanges, regions, err = DiffMap(map[testRegion]int(nil), map[testRegion]int{}, WithNilChanges(NilMap))
+	assert.Nil(t, err)
+	assert.False(t, hasChanges)
+	assert.Equal(t, ChangeMap[testRegion]{}, regions)
+
+	// Keys keep their type through Diff, paths and Apply.
+	before := map[string]map[int64]string{"items": {1: "a", 2: "b"}}
+	after := map[string]map[int64]string{"items": {1: "a", 2: "c"}}
+	_, changes, err := Diff("order", before, after)
+	assert.Nil(t, err)
+	flat := Flatten(changes)
+	assert.Equal(t, []FlatChange{
+		{Path: Path{"order", "items", int64(2)}, Kind: Modified, Before: "b", After: "c"},
+	}, flat)
+	assert.Equal(t, "order.items[2]", flat[0].Path.String())
+
+	target := map[string]map[int64]string{"items": {1: "a", 2: "b"}}
+	assert.Nil(t, Apply(&target, changes))
+	assert.Equal(t, after, target)
+}
@@ -54,6 +54,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"int": {
 					Key:       "int",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    1,
 					After:     2,
@@ -69,6 +70,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"int": {
 					Key:       "int",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    0,
 					After:     2,
@@ -84,6 +86,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"int": {
 					Key:       "int",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    -1,
 					After:     2,
@@ -109,6 +112,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"int": {
 					Key:       "int",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    int8(1),
 					After:     int8(2),
@@ -124,6 +128,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"int": {
 					Key:       "int",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    int8(0),
 					After:     int8(2),
@@ -139,6 +144,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"int": {
 					Key:       "int",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    int8(-2),
 					After:     int8(2),
@@ -164,6 +170,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"int": {
 					Key:       "int",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    int16(1),
 					After:     int16(2),
@@ -179,6 +186,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"int": {
 					Key:       "int",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    int16(0),
 					After:     int16(2),
@@ -194,6 +202,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"int": {
 					Key:       "int",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    int16(-1),
 					After:     int16(2),
@@ -219,6 +228,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"int": {
 					Key:       "int",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    int32(1),
 					After:     int32(2),
@@ -234,6 +244,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"int": {
 					Key:       "int",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    int32(0),
 					After:     int32(2),
@@ -249,6 +260,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"int": {
 					Key:       "int",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    int32(-1),
 					After:     int32(2),
@@ -274,6 +286,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"int": {
 					Key:       "int",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    int64(1),
 					After:     int64(2),
@@ -289,6 +302,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"int": {
 					Key:       "int",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    int64(0),
 					After:     int64(2),
@@ -314,6 +328,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"uint": {
 					Key:       "uint",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    uint(1),
 					After:     uint(2),
@@ -329,6 +344,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"uint": {
 					Key:       "uint",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    uint(0),
 					After:     uint(2),
@@ -354,6 +370,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"uint": {
 					Key:       "uint",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    uint8(1),
 					After:     uint8(2),
@@ -369,6 +386,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"uint": {
 					Key:       "uint",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    uint8(0),
 					After:     uint8(2),
@@ -394,6 +412,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"uint": {
 					Key:       "uint",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    uint16(1),
 					After:     uint16(2),
@@ -409,6 +428,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"uint": {
 					Key:       "uint",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    uint16(0),
 					After:     uint16(2),
@@ -434,6 +454,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"uint": {
 					Key:       "uint",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    uint32(1),
 					After:     uint32(2),
@@ -449,6 +470,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"uint": {
 					Key:       "uint",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    uint32(0),
 					After:     uint32(2),
@@ -474,6 +496,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"uint": {
 					Key:       "uint",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    uint64(1),
 					After:     uint64(2),
@@ -489,6 +512,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"uint": {
 					Key:       "uint",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    uint64(0),
 					After:     uint64(2),
@@ -514,6 +538,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"float": {
 					Key:       "float",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    float32(float32(1.2342)),
 					After:     float32(float32(1.2341)),
@@ -529,6 +554,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"float": {
 					Key:       "float",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    float32(float32(0.0)),
 					After:     float32(float32(2.78)),
@@ -554,6 +580,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"float": {
 					Key:       "float",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    float64(1.2342),
 					After:     float64(1.2341),
@@ -569,6 +596,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"float": {
 					Key:       "float",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    float64(0.0),
 					After:     float64(2.78),
@@ -594,6 +622,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"string": {
 					Key:       "string",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    "hello world!",
 					After:     "hallo world!",
@@ -609,6 +638,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"string": {
 					Key:       "string",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    "hello world!",
 					After:     "",
@@ -642,6 +672,7 @@ func TestPrimitive_EqualUnequal(t *testing.T) {
 			expectChanges: ChangeMap[string]{
 				"bool": {
 					Key:       "bool",
+					Kind:      Modified,
 					IsChanged: true,
 					Before:    true,
 					After:     false,
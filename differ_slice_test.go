@@ -1,5 +1,10 @@
 package differ
 
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
 // For list, with long-list use cases:
 //  - Zero item list, new item.
 //  - Only 1 or 2 fields changed within the list.
@@ -39,3 +44,341 @@ package differ
 //        - List of fields.
 //        - Map of fields.
 //        - Map of structs.
+
+func TestSlice(t *testing.T) {
+	type item struct {
+		ID    int
+		Name  string
+		Price float64
+	}
+
+	type testRow struct {
+		name   string
+		key    string
+		before any
+		after  any
+
+		expectHasChanges bool
+		expectChanges    ChangeMap[any]
+	}
+
+	runRows := func(t *testing.T, rows []*testRow) {
+		for _, r := range rows {
+			t.Run(r.name, func(t *testing.T) {
+				hasChanges, changes, err := DiffSlice(r.key, r.before, r.after)
+				assert.Nil(t, err)
+				assert.Equal(t, r.expectHasChanges, hasChanges)
+				if r.expectHasChanges == false {
+					assert.Equal(t, ChangeMap[string]{}, changes)
+					return
+				}
+				assert.Equal(t, r.expectChanges, changes[r.key].Changes)
+			})
+		}
+	}
+
+	list := []string{"a", "b", "c", "d", "e"}
+	items := []item{{1, "a", 1}, {2, "b", 2}, {3, "c", 3}}
+
+	runRows(t, []*testRow{
+		{
+			name:             "equal",
+			key:              "list",
+			before:           list,
+			after:            []string{"a", "b", "c", "d", "e"},
+			expectHasChanges: false,
+		},
+		{
+			name:             "both empty",
+			key:              "list",
+			before:           []string{},
+			after:            []string(nil),
+			expectHasChanges: false,
+		},
+		{
+			name:             "zero item list, new item",
+			key:              "list",
+			before:           []string{},
+			after:            []string{"a"},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[any]{
+				SliceIndex{-1, 0}: {Key: SliceIndex{-1, 0}, Kind: Added, IsNew: true, IsChanged: true, After: "a"},
+			},
+		},
+		{
+			name:             "1 item is inserted at the top",
+			key:              "list",
+			before:           list,
+			after:            []string{"x", "a", "b", "c", "d", "e"},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[any]{
+				SliceIndex{-1, 0}: {Key: SliceIndex{-1, 0}, Kind: Added, IsNew: true, IsChanged: true, After: "x"},
+			},
+		},
+		{
+			name:             "1 item is inserted in the middle, equal with an item at the bottom",
+			key:              "list",
+			before:           list,
+			after:            []string{"a", "b", "e", "c", "d", "e"},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[any]{
+				SliceIndex{-1, 2}: {Key: SliceIndex{-1, 2}, Kind: Added, IsNew: true, IsChanged: true, After: "e"},
+			},
+		},
+		{
+			name:             "1 item is inserted at the bottom",
+			key:              "list",
+			before:           list,
+			after:            []string{"a", "b", "c", "d", "e", "x"},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[any]{
+				SliceIndex{-1, 5}: {Key: SliceIndex{-1, 5}, Kind: Added, IsNew: true, IsChanged: true, After: "x"},
+			},
+		},
+		{
+			name:             "top items are deleted",
+			key:              "list",
+			before:           list,
+			after:            []string{"c", "d", "e"},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[any]{
+				SliceIndex{0, -1}: {Key: SliceIndex{0, -1}, Kind: Removed, IsChanged: true, Before: "a"},
+				SliceIndex{1, -1}: {Key: SliceIndex{1, -1}, Kind: Removed, IsChanged: true, Before: "b"},
+			},
+		},
+		{
+			name:             "middle item is deleted",
+			key:              "list",
+			before:           list,
+			after:            []string{"a", "b", "d", "e"},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[any]{
+				SliceIndex{2, -1}: {Key: SliceIndex{2, -1}, Kind: Removed, IsChanged: true, Before: "c"},
+			},
+		},
+		{
+			name:             "item is deleted until it becomes a zero item list",
+			key:              "list",
+			before:           []string{"a"},
+			after:            []string{},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[any]{
+				SliceIndex{0, -1}: {Key: SliceIndex{0, -1}, Kind: Removed, IsChanged: true, Before: "a"},
+			},
+		},
+		{
+			name:             "item is replaced",
+			key:              "list",
+			before:           list,
+			after:            []string{"a", "b", "x", "d", "e"},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[any]{
+				SliceIndex{2, 2}: {Key: SliceIndex{2, 2}, Kind: Modified, IsChanged: true, Before: "c", After: "x"},
+			},
+		},
+		{
+			name:             "only 1 field changed within the list",
+			key:              "items",
+			before:           items,
+			after:            []item{{1, "a", 1}, {2, "b", 2.5}, {3, "c", 3}},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[any]{
+				SliceIndex{1, 1}: {
+					Key:       SliceIndex{1, 1},
+					Kind:      Modified,
+					IsChanged: true,
+					Changes: ChangeMap[any]{
+						"Price": {Key: "Price", Kind: Modified, IsChanged: true, Before: float64(2), After: 2.5},
+					},
+				},
+			},
+		},
+		{
+			name:             "item inserted at the top and field changed in the middle",
+			key:              "items",
+			before:           items,
+			after:            []item{{0, "z", 0}, {1, "a", 1}, {2, "b", 2.5}, {3, "c", 3}},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[any]{
+				SliceIndex{-1, 0}: {Key: SliceIndex{-1, 0}, Kind: Added, IsNew: true, IsChanged: true, After: item{0, "z", 0}},
+				SliceIndex{1, 2}: {
+					Key:       SliceIndex{1, 2},
+					Kind:      Modified,
+					IsChanged: true,
+					Changes: ChangeMap[any]{
+						"Price": {Key: "Price", Kind: Modified, IsChanged: true, Before: float64(2), After: 2.5},
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestSlice_Bytes(t *testing.T) {
+	hasChanges, changes, err := Diff("bytes", []byte("hello"), []byte("hallo"))
+	assert.Nil(t, err)
+	assert.True(t, hasChanges)
+	assert.Equal(t, ChangeMap[string]{
+		"bytes": {Key: "bytes", Kind: Modified, IsChanged: true, Before: []byte("hello"), After: []byte("hallo")},
+	}, changes)
+}
+
+func TestSlice_Large(t *testing.T) {
+	before := make([]int, 1000)
+	after := make([]int, 1000)
+	for i := range before {
+		before[i] = i
+		after[i] = -i - 1
+	}
+
+	hasChanges, changes, err := Diff("list", before, after)
+	assert.Nil(t, err)
+	assert.True(t, hasChanges)
+	assert.Len(t, changes["list"].Changes, 1000)
+	assert.Equal(t, &ChangeField{
+		Key:       SliceIndex{999, 999},
+		Kind:      Modified,
+		IsChanged: true,
+		Before:    999,
+		After:     -1000,
+	}, changes["list"].Changes[SliceIndex{999, 999}])
+}
+
+func TestSlice_ByKey(t *testing.T) {
+	type role struct {
+		ID   string `differ:"key"`
+		Name string
+	}
+	type line struct {
+		SKU string
+		Qty int
+	}
+
+	type testRow struct {
+		name   string
+		before any
+		after  any
+		keyOf  func(elem any) any
+
+		expectError      bool
+		expectHasChanges bool
+		expectChanges    ChangeMap[any]
+	}
+
+	runRows := func(t *testing.T, rows []*testRow) {
+		for _, r := range rows {
+			t.Run(r.name, func(t *testing.T) {
+				var opts []Option
+				if r.keyOf != nil {
+					opts = append(opts, WithSliceKey(r.keyOf))
+				}
+				hasChanges, changes, err := DiffSlice("list", r.before, r.after, opts...)
+				if r.expectError {
+					assert.NotNil(t, err)
+					return
+				}
+
+				assert.Nil(t, err)
+				assert.Equal(t, r.expectHasChanges, hasChanges)
+				if r.expectHasChanges == false {
+					assert.Equal(t, ChangeMap[string]{}, changes)
+					return
+				}
+				assert.Equal(t, r.expectChanges, changes["list"].Changes)
+			})
+		}
+	}
+
+	roles := []role{{"a", "Admin"}, {"b", "Billing"}, {"c", "Customer"}}
+	lineKey := func(elem any) any {
+		return elem.(*line).SKU
+	}
+
+	runRows(t, []*testRow{
+		{
+			name:             "tag equal",
+			before:           roles,
+			after:            []role{{"a", "Admin"}, {"b", "Billing"}, {"c", "Customer"}},
+			expectHasChanges: false,
+		},
+		{
+			name:             "tag top item is deleted",
+			before:           roles,
+			after:            []role{{"b", "Billing"}, {"c", "Customer"}},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[any]{
+				SliceIndex{0, -1}: {Key: SliceIndex{0, -1}, Kind: Removed, IsChanged: true, Before: role{"a", "Admin"}},
+			},
+		},
+		{
+			name:             "tag item is modified after another item is deleted",
+			before:           roles,
+			after:            []role{{"b", "Billing"}, {"c", "Client"}},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[any]{
+				SliceIndex{0, -1}: {Key: SliceIndex{0, -1}, Kind: Removed, IsChanged: true, Before: role{"a", "Admin"}},
+				SliceIndex{2, 1}: {
+					Key:       SliceIndex{2, 1},
+					Kind:      Modified,
+					IsChanged: true,
+					Changes: ChangeMap[any]{
+						"Name": {Key: "Name", Kind: Modified, IsChanged: true, Before: "Customer", After: "Client"},
+					},
+				},
+			},
+		},
+		{
+			name:             "tag item is moved",
+			before:           roles,
+			after:            []role{{"b", "Billing"}, {"c", "Customer"}, {"a", "Admin"}},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[any]{
+				SliceIndex{0, 2}: {Key: SliceIndex{0, 2}, Kind: Moved, IsChanged: true, Before: role{"a", "Admin"}, After: role{"a", "Admin"}},
+			},
+		},
+		{
+			name:             "tag item is added",
+			before:           roles,
+			after:            []role{{"a", "Admin"}, {"d", "Dev"}, {"b", "Billing"}, {"c", "Customer"}},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[any]{
+				SliceIndex{-1, 1}: {Key: SliceIndex{-1, 1}, Kind: Added, IsNew: true, IsChanged: true, After: role{"d", "Dev"}},
+			},
+		},
+		{
+			name:             "tag zero keys are never matched",
+			before:           []role{{"a", "Admin"}, {"", "Old"}},
+			after:            []role{{"a", "Admin"}, {"", "New"}, {"", "Other"}},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[any]{
+				SliceIndex{1, -1}: {Key: SliceIndex{1, -1}, Kind: Removed, IsChanged: true, Before: role{"", "Old"}},
+				SliceIndex{-1, 1}: {Key: SliceIndex{-1, 1}, Kind: Added, IsNew: true, IsChanged: true, After: role{"", "New"}},
+				SliceIndex{-1, 2}: {Key: SliceIndex{-1, 2}, Kind: Added, IsNew: true, IsChanged: true, After: role{"", "Other"}},
+			},
+		},
+		{
+			name:        "tag duplicate key",
+			before:      roles,
+			after:       []role{{"a", "Admin"}, {"a", "Billing"}},
+			expectError: true,
+		},
+		{
+			name:             "func item is modified and moved",
+			before:           []*line{{"x", 1}, {"y", 2}},
+			after:            []*line{{"y", 3}, {"x", 1}},
+			keyOf:            lineKey,
+			expectHasChanges: true,
+			expectChanges: ChangeMap[any]{
+				SliceIndex{0, 1}: {Key: SliceIndex{0, 1}, Kind: Moved, IsChanged: true, Before: line{"x", 1}, After: line{"x", 1}},
+				SliceIndex{1, 0}: {
+					Key:       SliceIndex{1, 0},
+					Kind:      Modified,
+					IsChanged: true,
+					Changes: ChangeMap[any]{
+						"Qty": {Key: "Qty", Kind: Modified, IsChanged: true, Before: 2, After: 3},
+					},
+				},
+			},
+		},
+	})
+}
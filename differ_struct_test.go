@@ -1,6 +1,566 @@
 package differ
 
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"net"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
 // Test struct value
 // Test pointer to struct
 // Test pointer to slice
 // Test pointer to maps
+
+type testAddress struct {
+	Street  string `json:"street"`
+	Number  int64  `json:"number,omitempty"`
+	Ignored string `json:"-"`
+}
+
+type testBase struct {
+	ID      int64
+	Version int
+}
+
+type testUser struct {
+	testBase
+	Name    string       `json:"name"`
+	Address *testAddress `json:"address"`
+	Tags    any          `json:"tags"`
+	note    string
+}
+
+type testTagged struct {
+	Email     string       `json:"email" differ:"name=Email Address"`
+	Password  string       `json:"password" differ:"-"`
+	Secret    string       `json:"-" differ:"name=secret"`
+	Hidden    string       `json:"-"`
+	Nickname  string       `differ:"omitempty"`
+	Manager   *testAddress `differ:"omitempty"`
+	UpdatedAt int64        `json:"updatedAt"`
+}
+
+// testMoney diffs itself as a single amount instead of its fields.
+type testMoney struct {
+	Units int64
+	Nanos int32
+}
+
+func (m testMoney) Diff(after any) (ChangeMap[any], error) {
+	a := after.(testMoney)
+	before := float64(m.Units) + float64(m.Nanos)/1e9
+	amount := float64(a.Units) + float64(a.Nanos)/1e9
+	if before == amount {
+		return nil, nil
+	}
+	return ChangeMap[any]{
+		"amount": {Key: "amount", Kind: Modified, IsChanged: true, Before: before, After: amount},
+	}, nil
+}
+
+func TestStruct(t *testing.T) {
+	type testRow struct {
+		name   string
+		key    string
+		before any
+		after  any
+
+		expectError      bool
+		expectHasChanges bool
+		expectChanges    any
+	}
+
+	runRows := func(t *testing.T, rows []*testRow) {
+		for _, r := range rows {
+			t.Run(r.name, func(t *testing.T) {
+				hasChanges, changes, err := Diff(r.key, r.before, r.after)
+				if r.expectError {
+					assert.NotNil(t, err)
+					assert.Equal(t, false, hasChanges)
+					assert.Equal(t, ChangeMap[string](nil), changes)
+					return
+				}
+
+				assert.Nil(t, err)
+				assert.Equal(t, r.expectHasChanges, hasChanges)
+				assert.Equal(t, r.expectChanges, changes)
+			})
+		}
+	}
+
+	runRows(t, []*testRow{
+		{
+			name:             "struct equal",
+			key:              "user",
+			before:           testUser{Name: "Rick", Address: &testAddress{Street: "Main"}},
+			after:            testUser{Name: "Rick", Address: &testAddress{Street: "Main"}},
+			expectHasChanges: false,
+			expectChanges:    ChangeMap[string]{},
+		},
+		{
+			name:             "pointer to struct equal",
+			key:              "user",
+			before:           &testUser{Name: "Rick"},
+			after:            &testUser{Name: "Rick"},
+			expectHasChanges: false,
+			expectChanges:    ChangeMap[string]{},
+		},
+		{
+			name:             "field changed",
+			key:              "user",
+			before:           testUser{Name: "Rick"},
+			after:            &testUser{Name: "Morty"},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[string]{
+				"user": {
+					Key:       "user",
+					Kind:      Modified,
+					IsChanged: true,
+					Changes: ChangeMap[any]{
+						"name": {
+							Key:       "name",
+							Kind:      Modified,
+							IsChanged: true,
+							Before:    "Rick",
+							After:     "Morty",
+						},
+					},
+				},
+			},
+		},
+		{
+			name:             "embedded fields are promoted and keep their type",
+			key:              "user",
+			before:           testUser{testBase: testBase{ID: 1, Version: 1}},
+			after:            testUser{testBase: testBase{ID: 1, Version: 2}},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[string]{
+				"user": {
+					Key:       "user",
+					Kind:      Modified,
+					IsChanged: true,
+					Changes: ChangeMap[any]{
+						"Version": {
+							Key:       "Version",
+							Kind:      Modified,
+							IsChanged: true,
+							Before:    1,
+							After:     2,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:             "nested struct changed",
+			key:              "user",
+			before:           testUser{Address: &testAddress{Street: "Main", Number: 1, Ignored: "a"}},
+			after:            testUser{Address: &testAddress{Street: "Main", Number: 2, Ignored: "b"}},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[string]{
+				"user": {
+					Key:       "user",
+					Kind:      Modified,
+					IsChanged: true,
+					Changes: ChangeMap[any]{
+						"address": {
+							Key:       "address",
+							Kind:      Modified,
+							IsChanged: true,
+							Changes: ChangeMap[any]{
+								"number": {
+									Key:       "number",
+									Kind:      Modified,
+									IsChanged: true,
+									Before:    int64(1),
+									After:     int64(2),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:             "nested struct set from nil",
+			key:              "user",
+			before:           testUser{},
+			after:            testUser{Address: &testAddress{Street: "Main"}},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[string]{
+				"user": {
+					Key:       "user",
+					Kind:      Modified,
+					IsChanged: true,
+					Changes: ChangeMap[any]{
+						"address": {
+							Key:       "address",
+							Kind:      Modified,
+							IsChanged: true,
+							Before:    nil,
+							After:     testAddress{Street: "Main"},
+							BeforeNil: NilPointer,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:             "nested struct set to nil",
+			key:              "user",
+			before:           testUser{Address: &testAddress{Street: "Main"}},
+			after:            testUser{},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[string]{
+				"user": {
+					Key:       "user",
+					Kind:      Modified,
+					IsChanged: true,
+					Changes: ChangeMap[any]{
+						"address": {
+							Key:       "address",
+							Kind:      Modified,
+							IsChanged: true,
+							Before:    testAddress{Street: "Main"},
+							After:     nil,
+							AfterNil:  NilPointer,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:             "interface field changed type",
+			key:              "user",
+			before:           testUser{Tags: 1},
+			after:            testUser{Tags: "1"},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[string]{
+				"user": {
+					Key:       "user",
+					Kind:      Modified,
+					IsChanged: true,
+					Changes: ChangeMap[any]{
+						"tags": {
+							Key:        "tags",
+							Kind:       TypeChanged,
+							IsChanged:  true,
+							Before:     1,
+							After:      "1",
+							BeforeType: reflect.TypeOf(0),
+							AfterType:  reflect.TypeOf(""),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:             "unexported field changed",
+			key:              "user",
+			before:           testUser{note: "a"},
+			after:            testUser{note: "b"},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[string]{
+				"user": {
+					Key:       "user",
+					Kind:      Modified,
+					IsChanged: true,
+					Changes: ChangeMap[any]{
+						"note": {
+							Key:       "note",
+							Kind:      Modified,
+							IsChanged: true,
+							Before:    "a",
+							After:     "b",
+							JSONName:  "-",
+						},
+					},
+				},
+			},
+		},
+		{
+			name:             "differ tag names and skips fields",
+			key:              "user",
+			before:           testTagged{Email: "a", Password: "a", Secret: "a", Hidden: "a", UpdatedAt: 1},
+			after:            testTagged{Email: "b", Password: "b", Secret: "b", Hidden: "b", UpdatedAt: 2},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[string]{
+				"user": {
+					Key:       "user",
+					Kind:      Modified,
+					IsChanged: true,
+					Changes: ChangeMap[any]{
+						"Email Address": {
+							Key:       "Email Address",
+							Kind:      Modified,
+							IsChanged: true,
+							Before:    "a",
+							After:     "b",
+							JSONName:  "email",
+						},
+						"secret":    {Key: "secret", Kind: Modified, IsChanged: true, Before: "a", After: "b", JSONName: "-"},
+						"updatedAt": {Key: "updatedAt", Kind: Modified, IsChanged: true, Before: int64(1), After: int64(2)},
+					},
+				},
+			},
+		},
+		{
+			name:             "differ tag omitempty added",
+			key:              "user",
+			before:           testTagged{},
+			after:            testTagged{Nickname: "b", Manager: &testAddress{Street: "Main"}},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[string]{
+				"user": {
+					Key:       "user",
+					Kind:      Modified,
+					IsChanged: true,
+					Changes: ChangeMap[any]{
+						"Nickname": {Key: "Nickname", Kind: Added, IsNew: true, IsChanged: true, After: "b", JSONEmpty: true},
+						"Manager": {
+							Key:       "Manager",
+							Kind:      Added,
+							IsNew:     true,
+							IsChanged: true,
+							After:     testAddress{Street: "Main"},
+							BeforeNil: NilPointer,
+							JSONEmpty: true,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:             "differ tag omitempty removed and modified",
+			key:              "user",
+			before:           testTagged{Nickname: "a", Manager: &testAddress{Street: "Main"}},
+			after:            testTagged{Manager: &testAddress{Street: "Side"}},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[string]{
+				"user": {
+					Key:       "user",
+					Kind:      Modified,
+					IsChanged: true,
+					Changes: ChangeMap[any]{
+						"Nickname": {Key: "Nickname", Kind: Removed, IsChanged: true, Before: "a", JSONEmpty: true},
+						"Manager": {
+							Key:       "Manager",
+							Kind:      Modified,
+							IsChanged: true,
+							Changes: ChangeMap[any]{
+								"street": {Key: "street", Kind: Modified, IsChanged: true, Before: "Main", After: "Side"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:        "unsupported type",
+			key:         "func",
+			before:      struct{ Fn func() }{},
+			after:       struct{ Fn func() }{},
+			expectError: true,
+		},
+		{
+			name:             "differ equal",
+			key:              "price",
+			before:           &testMoney{Units: 1, Nanos: 500000000},
+			after:            &testMoney{Units: 1, Nanos: 500000000},
+			expectHasChanges: false,
+			expectChanges:    ChangeMap[string]{},
+		},
+		{
+			name:             "differ changed",
+			key:              "price",
+			before:           map[string]testMoney{"a": {Units: 1, Nanos: 500000000}},
+			after:            map[string]testMoney{"a": {Units: 2}},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[string]{
+				"price": {
+					Key:       "price",
+					Kind:      Modified,
+					IsChanged: true,
+					Changes: ChangeMap[any]{
+						"a": {
+							Key:       "a",
+							Kind:      Modified,
+							IsChanged: true,
+							Changes: ChangeMap[any]{
+								"amount": {Key: "amount", Kind: Modified, IsChanged: true, Before: 1.5, After: 2.0},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+type testNode struct {
+	Name     string
+	Parent   *testNode
+	Children []*testNode
+}
+
+func TestStruct_Cycle(t *testing.T) {
+	tree := func(childName string) *testNode {
+		parent := &testNode{Name: "root"}
+		child := &testNode{Name: childName, Parent: parent}
+		parent.Children = []*testNode{child}
+		return parent
+	}
+
+	hasChanges, changes, err := Diff("tree", tree("a"), tree("a"))
+	assert.Nil(t, err)
+	assert.False(t, hasChanges)
+	assert.Equal(t, ChangeMap[string]{}, changes)
+
+	hasChanges, changes, err = Diff("tree", tree("a"), tree("b"))
+	assert.Nil(t, err)
+	assert.True(t, hasChanges)
+	assert.Equal(t, []FlatChange{
+		{Path: Path{"tree", "Children", 0, "Name"}, Kind: Modified, Before: "a", After: "b"},
+	}, Flatten(changes))
+
+	// The reference back to the parent is reported as a cycle with unchanged fields.
+	_, changes, err = Diff("tree", tree("a"), tree("b"), WithUnchanged())
+	assert.Nil(t, err)
+	child := changes["tree"].Changes["Children"].Changes[SliceIndex{0, 0}]
+	assert.Equal(t, &ChangeField{Key: "Parent", Kind: Unchanged, Cycle: true}, child.Changes["Parent"])
+
+	// A map that contains itself.
+	before := map[string]any{"name": "a"}
+	before["self"] = before
+	after := map[string]any{"name": "b"}
+	after["self"] = after
+	_, changes, err = Diff("map", before, after)
+	assert.Nil(t, err)
+	assert.Equal(t, []FlatChange{
+		{Path: Path{"map", "name"}, Kind: Modified, Before: "a", After: "b"},
+	}, Flatten(changes))
+}
+
+func TestStruct_StrictTypes(t *testing.T) {
+	before := testUser{Name: "Rick", Tags: map[string]any{"a": 1}}
+	after := testUser{Name: "Rick", Tags: map[string]any{"a": "1"}}
+
+	hasChanges, changes, err := Diff("user", before, after, WithStrictTypes())
+	assert.True(t, errors.Is(err, ErrNotTheSameType))
+	assert.Contains(t, err.Error(), "user.tags.a")
+	assert.False(t, hasChanges)
+	assert.Nil(t, changes)
+
+	// Values that keep their type are fine.
+	hasChanges, _, err = Diff("user", before, testUser{Name: "Rick", Tags: map[string]any{"a": 2}}, WithStrictTypes())
+	assert.Nil(t, err)
+	assert.True(t, hasChanges)
+
+	// List items of another type that are only removed or added don't change type.
+	hasChanges, changes, err = Diff("x", []any{1, "a"}, []any{"a"}, WithStrictTypes())
+	assert.Nil(t, err)
+	assert.True(t, hasChanges)
+	assert.Equal(t, ChangeMap[any]{
+		SliceIndex{0, -1}: {Key: SliceIndex{0, -1}, Kind: Removed, IsChanged: true, Before: 1},
+	}, changes["x"].Changes)
+
+	// Items that are paired still do.
+	_, _, err = Diff("x", []any{1, "a"}, []any{"b", "a"}, WithStrictTypes())
+	assert.True(t, errors.Is(err, ErrNotTheSameType))
+	assert.Contains(t, err.Error(), "x[0]")
+}
+
+type testEvent struct {
+	At      time.Time
+	Timeout time.Duration
+	Amount  *big.Int
+	Rate    big.Float
+	Source  net.IP
+	Link    *url.URL
+	ID      [16]byte
+}
+
+func TestStruct_Leaves(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	link, _ := url.Parse("https://example.com/a?b=c")
+	before := testEvent{
+		At:      at,
+		Timeout: time.Second,
+		Amount:  big.NewInt(100),
+		Rate:    *big.NewFloat(1.5),
+		Source:  net.ParseIP("10.0.0.1").To4(),
+		Link:    link,
+		ID:      [16]byte{1, 2, 3},
+	}
+
+	// The same instant in another location, an IPv4 address in its IPv6 form and copies of the other values are
+	// the same.
+	same := testEvent{
+		At:      at.In(jakarta),
+		Timeout: time.Second,
+		Amount:  big.NewInt(100),
+		Rate:    *big.NewFloat(1.5),
+		Source:  net.ParseIP("10.0.0.1"),
+		Link:    &url.URL{Scheme: "https", Host: "example.com", Path: "/a", RawQuery: "b=c"},
+		ID:      [16]byte{1, 2, 3},
+	}
+	hasChanges, _, err := Diff("event", before, same)
+	assert.Nil(t, err)
+	assert.False(t, hasChanges)
+
+	after := testEvent{
+		At:      at.Add(time.Hour),
+		Timeout: time.Minute,
+		Amount:  big.NewInt(200),
+		Rate:    *big.NewFloat(2.5),
+		Source:  net.ParseIP("10.0.0.2"),
+		Link:    &url.URL{Scheme: "https", Host: "example.org"},
+		ID:      [16]byte{1, 2, 4},
+	}
+	hasChanges, changes, err := Diff("event", before, after)
+	assert.Nil(t, err)
+	assert.True(t, hasChanges)
+	assert.Equal(t, ChangeMap[any]{
+		"At":      {Key: "At", Kind: Modified, IsChanged: true, Before: at, After: at.Add(time.Hour)},
+		"Timeout": {Key: "Timeout", Kind: Modified, IsChanged: true, Before: time.Second, After: time.Minute},
+		"Amount":  {Key: "Amount", Kind: Modified, IsChanged: true, Before: big.NewInt(100), After: big.NewInt(200)},
+		"Rate": {
+			Key:       "Rate",
+			Kind:      Modified,
+			IsChanged: true,
+			Before:    big.NewFloat(1.5),
+			After:     big.NewFloat(2.5),
+		},
+		"Source": {
+			Key:       "Source",
+			Kind:      Modified,
+			IsChanged: true,
+			Before:    net.ParseIP("10.0.0.1").To4(),
+			After:     net.ParseIP("10.0.0.2"),
+		},
+		"Link": {
+			Key:       "Link",
+			Kind:      Modified,
+			IsChanged: true,
+			Before:    link,
+			After:     &url.URL{Scheme: "https", Host: "example.org"},
+		},
+		"ID": {Key: "ID", Kind: Modified, IsChanged: true, Before: [16]byte{1, 2, 3}, After: [16]byte{1, 2, 4}},
+	}, changes["event"].Changes)
+
+	// The changes apply back onto the original types.
+	target := before
+	target.Amount = big.NewInt(100)
+	assert.Nil(t, Apply(&target, changes))
+	assert.Equal(t, 0, target.Amount.Cmp(after.Amount))
+	assert.Equal(t, 0, target.Rate.Cmp(&after.Rate))
+	assert.Equal(t, after.Link.String(), target.Link.String())
+	assert.Equal(t, after.ID, target.ID)
+	assert.True(t, target.At.Equal(after.At))
+}
@@ -1,5 +1,66 @@
 package differ
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
 
-var ErrNotTheSameType = errors.New("not the same type")
+var (
+	// ErrNotTheSameType is returned by Diff with WithStrictTypes when a value changes to a different type.
+	ErrNotTheSameType = errors.New("not the same type")
+	// ErrUnsupportedKind is returned by Diff when it reaches a value that can't be compared, like a chan or a func.
+	ErrUnsupportedKind = errors.New("unsupported kind")
+	// ErrCycle is returned by Diff with WithCycleError when a value refers back to a value that is being diffed.
+	ErrCycle = errors.New("cycle")
+	// ErrMaxDepth is returned by Diff with WithMaxDepthError when a value deeper than the max depth has changed.
+	ErrMaxDepth = errors.New("max depth exceeded")
+)
+
+// DiffError is returned by Diff when it can't diff a value. Err is the cause, which is one of the errors above or the
+// error returned by a Differ, use errors.Is to check for it.
+type DiffError struct {
+	Path       Path
+	BeforeType reflect.Type
+	AfterType  reflect.Type
+	Err        error
+}
+
+// newDiffError returns a *DiffError for the values at the given path.
+func newDiffError(path Path, before reflect.Value, after reflect.Value, err error) *DiffError {
+	e := &DiffError{Path: path, Err: err}
+	if before.IsValid() {
+		e.BeforeType = before.Type()
+	}
+	if after.IsValid() {
+		e.AfterType = after.Type()
+	}
+	return e
+}
+
+func (e *DiffError) Error() string {
+	types := fmt.Sprint(e.BeforeType)
+	if e.AfterType != e.BeforeType {
+		types += " and " + fmt.Sprint(e.AfterType)
+	}
+	return fmt.Sprintf("diff: %s: %v (%s)", e.Path, e.Err, types)
+}
+
+func (e *DiffError) Unwrap() error {
+	return e.Err
+}
+
+// ConflictError is returned by Apply when the current value in the target is not the same as the Before recorded in
+// the change, or when the value to change doesn't exist in the target.
+type ConflictError struct {
+	Path    Path
+	Before  any
+	Current any
+}
+
+func (e *ConflictError) Error() string {
+	if e.Before == nil && e.Current == nil {
+		return fmt.Sprintf("apply: conflict at %s: value doesn't exist", e.Path)
+	}
+	return fmt.Sprintf("apply: conflict at %s: expected %v, found %v", e.Path, e.Before, e.Current)
+}
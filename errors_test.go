@@ -0,0 +1,210 @@
+package differ
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+type testFailingDiffer struct{}
+
+var errTestDiffer = errors.New("cannot diff")
+
+func (testFailingDiffer) Diff(after any) (ChangeMap[any], error) {
+	return nil, errTestDiffer
+}
+
+func TestDiffError(t *testing.T) {
+	type holder struct {
+		Items  []any
+		Parent *holder
+	}
+
+	type testRow struct {
+		name   string
+		before any
+		after  any
+		opts   []Option
+
+		expectErr   error
+		expectError *DiffError
+		expectText  string
+	}
+
+	runRows := func(t *testing.T, rows []*testRow) {
+		for _, r := range rows {
+			t.Run(r.name, func(t *testing.T) {
+				_, _, err := Diff("root", r.before, r.after, r.opts...)
+				assert.True(t, errors.Is(err, r.expectErr))
+
+				var diffErr *DiffError
+				assert.True(t, errors.As(err, &diffErr))
+				assert.Equal(t, r.expectError, diffErr)
+				assert.Equal(t, r.expectText, err.Error())
+			})
+		}
+	}
+
+	node := &holder{}
+	node.Parent = node
+	otherNode := &holder{}
+	otherNode.Parent = otherNode
+
+	runRows(t, []*testRow{
+		{
+			name:      "unsupported kind",
+			before:    holder{Items: []any{1, make(chan int)}},
+			after:     holder{Items: []any{1, make(chan int)}},
+			expectErr: ErrUnsupportedKind,
+			expectError: &DiffError{
+				Path:       Path{"root", "Items", 1},
+				BeforeType: reflect.TypeOf(make(chan int)),
+				AfterType:  reflect.TypeOf(make(chan int)),
+				Err:        ErrUnsupportedKind,
+			},
+			expectText: "diff: root.Items[1]: unsupported kind (chan int)",
+		},
+		{
+			name:      "not the same type",
+			before:    []any{1},
+			after:     []any{"1"},
+			opts:      []Option{WithStrictTypes(), WithSliceMatching(MatchIndex)},
+			expectErr: ErrNotTheSameType,
+			expectError: &DiffError{
+				Path:       Path{"root", 0},
+				BeforeType: reflect.TypeOf(0),
+				AfterType:  reflect.TypeOf(""),
+				Err:        ErrNotTheSameType,
+			},
+			expectText: "diff: root[0]: not the same type (int and string)",
+		},
+		{
+			name:      "cycle",
+			before:    node,
+			after:     otherNode,
+			opts:      []Option{WithCycleError()},
+			expectErr: ErrCycle,
+			expectError: &DiffError{
+				Path:       Path{"root", "Parent"},
+				BeforeType: reflect.TypeOf(holder{}),
+				AfterType:  reflect.TypeOf(holder{}),
+				Err:        ErrCycle,
+			},
+			expectText: "diff: root.Parent: cycle (differ.holder)",
+		},
+		{
+			name:      "max depth",
+			before:    map[string][]int{"a": {1}},
+			after:     map[string][]int{"a": {2}},
+			opts:      []Option{WithMaxDepth(1), WithMaxDepthError()},
+			expectErr: ErrMaxDepth,
+			expectError: &DiffError{
+				Path:       Path{"root", "a"},
+				BeforeType: reflect.TypeOf([]int{}),
+				AfterType:  reflect.TypeOf([]int{}),
+				Err:        ErrMaxDepth,
+			},
+			expectText: "diff: root.a: max depth exceeded ([]int)",
+		},
+		{
+			name:      "below max depth",
+			before:    map[string]map[string]any{"a": {"c": make(chan int)}},
+			after:     map[string]map[string]any{"a": {"c": make(chan int)}},
+			opts:      []Option{WithMaxDepth(1)},
+			expectErr: ErrUnsupportedKind,
+			expectError: &DiffError{
+				Path:       Path{"root", "a", "c"},
+				BeforeType: reflect.TypeOf(make(chan int)),
+				AfterType:  reflect.TypeOf(make(chan int)),
+				Err:        ErrUnsupportedKind,
+			},
+			expectText: "diff: root.a.c: unsupported kind (chan int)",
+		},
+		{
+			name:      "differ",
+			before:    map[string]testFailingDiffer{"a": {}},
+			after:     map[string]testFailingDiffer{"a": {}},
+			expectErr: errTestDiffer,
+			expectError: &DiffError{
+				Path:       Path{"root", "a"},
+				BeforeType: reflect.TypeOf(testFailingDiffer{}),
+				AfterType:  reflect.TypeOf(testFailingDiffer{}),
+				Err:        errTestDiffer,
+			},
+			expectText: "diff: root.a: cannot diff (differ.testFailingDiffer)",
+		},
+	})
+}
+
+func TestDiffError_DiffMap(t *testing.T) {
+	_, _, err := DiffMap(map[int]any{1: func() {}}, map[int]any{1: func() {}})
+	var diffErr *DiffError
+	assert.True(t, errors.As(err, &diffErr))
+	assert.Equal(t, Path{1}, diffErr.Path)
+}
+
+func TestDiffError_MaxDepthUnchanged(t *testing.T) {
+	// Values at the max depth that have not changed are fine.
+	hasChanges, _, err := Diff("root", map[string][]int{"a": {1}}, map[string][]int{"a": {1}}, WithMaxDepth(1), WithMaxDepthError())
+	assert.Nil(t, err)
+	assert.False(t, hasChanges)
+
+	// Neither are list items that are only removed.
+	type item struct {
+		M map[string]int
+	}
+	a, b := map[string]int{"a": 1}, map[string]int{"b": 1}
+	hasChanges, changes, err := Diff("x", []item{{M: a}, {M: b}}, []item{{M: b}}, WithMaxDepth(2), WithMaxDepthError())
+	assert.Nil(t, err)
+	assert.True(t, hasChanges)
+	assert.Equal(t, ChangeMap[any]{
+		SliceIndex{0, -1}: {Key: SliceIndex{0, -1}, Kind: Removed, IsChanged: true, Before: item{M: a}},
+	}, changes["x"].Changes)
+
+	// Items that are paired still exceed the max depth.
+	_, _, err = Diff("x", []item{{M: a}}, []item{{M: b}}, WithMaxDepth(2), WithMaxDepthError())
+	assert.True(t, errors.Is(err, ErrMaxDepth))
+}
+
+func TestDiffError_CollectErrors(t *testing.T) {
+	type holder struct {
+		Name    string
+		Updates chan int
+		Items   []any
+	}
+
+	before := holder{Name: "a", Updates: make(chan int), Items: []any{1, func() {}}}
+	after := holder{Name: "b", Updates: make(chan int), Items: []any{2, func() {}}}
+
+	// By default the first error stops the diff.
+	_, changes, err := Diff("root", before, after)
+	assert.True(t, errors.Is(err, ErrUnsupportedKind))
+	assert.Nil(t, changes)
+
+	hasChanges, changes, err := Diff("root", before, after, WithCollectErrors())
+	assert.True(t, hasChanges)
+	assert.True(t, errors.Is(err, ErrUnsupportedKind))
+	assert.Equal(t, ""+
+		"diff: root.Items[1]: unsupported kind (func())\n"+
+		"diff: root.Updates: unsupported kind (chan int)", err.Error())
+
+	updates := changes["root"].Changes["Updates"]
+	assert.Equal(t, Failed, updates.Kind)
+	assert.True(t, errors.Is(updates.Err, ErrUnsupportedKind))
+	assert.Equal(t, []FlatChange{
+		{Path: Path{"root", "Items", 0}, Kind: Modified, Before: 1, After: 2},
+		{Path: Path{"root", "Items", 1}, Kind: Failed},
+		{Path: Path{"root", "Name"}, Kind: Modified, Before: "a", After: "b"},
+		{Path: Path{"root", "Updates"}, Kind: Failed},
+	}, Flatten(changes))
+	assert.Equal(t, ""+
+		"  root.Items[0]: 1 → 2\n"+
+		"! root.Items[1]: diff: root.Items[1]: unsupported kind (func())\n"+
+		"  root.Name: \"a\" → \"b\"\n"+
+		"! root.Updates: diff: root.Updates: unsupported kind (chan int)\n", Render(changes, RenderOptions{}))
+
+	// Failed values can't be applied.
+	target := before
+	assert.NotNil(t, Apply(&target, changes))
+}
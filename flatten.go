@@ -0,0 +1,37 @@
+package differ
+
+// FlatChange is a single changed value with its full path, as returned by Flatten.
+type FlatChange struct {
+	Path   Path
+	Kind   ChangeKind
+	Before any
+	After  any
+}
+
+// Flatten returns the changes as a flat list, sorted by path. Only values that have no nested changes are returned,
+// changes within structs, maps and lists are returned with the path to them instead. Use Path.String or
+// Path.JSONPointer to get the path as dotted notation or as JSON Pointer.
+//
+// Values matched by one of the formatters are replaced by their display string, so the list can be exported as is.
+func Flatten[K comparable](changes ChangeMap[K], formatters ...Formatter) []FlatChange {
+	f := newFormatters(formatters)
+	format := func(path Path, value any) any {
+		if formatted, ok := f.format(path, value); ok {
+			return formatted
+		}
+		return value
+	}
+
+	var flat []FlatChange
+	for _, field := range sortedFields(changes) {
+		walkLeaves(nil, field, func(path Path, field *ChangeField) {
+			flat = append(flat, FlatChange{
+				Path:   path,
+				Kind:   field.Kind,
+				Before: format(path, field.Before),
+				After:  format(path, field.After),
+			})
+		})
+	}
+	return flat
+}
@@ -0,0 +1,56 @@
+package differ
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFlatten(t *testing.T) {
+	before := map[string]any{
+		"items": []map[string]any{{"price": 10.5}, {"price": 3}},
+		"a/b~c": "x",
+		"tags":  []string{"new"},
+	}
+	after := map[string]any{
+		"items": []map[string]any{{"price": 12.0}, {"price": 3}},
+		"a/b~c": "y",
+		"tags":  []string{"new", "vip"},
+	}
+	_, changes, err := Diff("order", before, after)
+	assert.Nil(t, err)
+
+	flat := Flatten(changes)
+	assert.Equal(t, []FlatChange{
+		{Path: Path{"order", "a/b~c"}, Kind: Modified, Before: "x", After: "y"},
+		{Path: Path{"order", "items", 0, "price"}, Kind: Modified, Before: 10.5, After: 12.0},
+		{Path: Path{"order", "tags", 1}, Kind: Added, Before: nil, After: "vip"},
+	}, flat)
+
+	var pointers, dotted []string
+	for _, change := range flat {
+		pointers = append(pointers, change.Path.JSONPointer())
+		dotted = append(dotted, change.Path.String())
+	}
+	assert.Equal(t, []string{"/order/a~1b~0c", "/order/items/0/price", "/order/tags/1"}, pointers)
+	assert.Equal(t, []string{"order.a/b~c", "order.items[0].price", "order.tags[1]"}, dotted)
+}
+
+func TestFlatten_Formatters(t *testing.T) {
+	_, changes, err := Diff("order", map[string]int{"price": 1200, "qty": 1}, map[string]int{"price": 1500, "qty": 2})
+	assert.Nil(t, err)
+
+	flat := Flatten(changes, Formatter{Path: "price", Format: func(value any) string {
+		return fmt.Sprintf("$%d.%02d", value.(int)/100, value.(int)%100)
+	}})
+	assert.Equal(t, []FlatChange{
+		{Path: Path{"order", "price"}, Kind: Modified, Before: "$12.00", After: "$15.00"},
+		{Path: Path{"order", "qty"}, Kind: Modified, Before: 1, After: 2},
+	}, flat)
+}
+
+func TestFlatten_Empty(t *testing.T) {
+	_, changes, err := Diff("order", 1, 1)
+	assert.Nil(t, err)
+	assert.Nil(t, Flatten(changes))
+}
@@ -0,0 +1,64 @@
+package differ
+
+import (
+	"reflect"
+)
+
+// Formatter converts the values at a path, or the values of a type, to display strings when rendering or flattening
+// changes, for example cents as "$12.00" or an enum int as its name.
+type Formatter struct {
+	// Path matches the path of the change below the key given to Diff, like "items[*].price", the same way as
+	// WithIgnoredPaths, wildcards included. A path that can't be parsed never matches.
+	Path string
+	// Type matches values of the type, used when Path is empty.
+	Type reflect.Type
+	// Format returns the display string of a value. It's never called with nil.
+	Format func(value any) string
+}
+
+// formatter is a Formatter with its path parsed.
+type formatter struct {
+	Formatter
+	pattern pathPattern
+}
+
+// formatters is the list of formatters given to Render or Flatten, in the order they were given.
+type formatters []formatter
+
+func newFormatters(list []Formatter) formatters {
+	var f formatters
+	for _, item := range list {
+		if item.Format == nil {
+			continue
+		}
+		var pattern pathPattern
+		if item.Path != "" {
+			var err error
+			pattern, err = parsePathPattern(item.Path)
+			if err != nil {
+				continue
+			}
+		}
+		f = append(f, formatter{Formatter: item, pattern: pattern})
+	}
+	return f
+}
+
+// format returns the display string of the value at the given path from the first formatter that matches it.
+// Formatters with a path are tried before formatters with a type.
+func (f formatters) format(path Path, value any) (string, bool) {
+	if value == nil {
+		return "", false
+	}
+	for _, item := range f {
+		if item.pattern != nil && len(path) > 1 && item.pattern.match(path[1:]) {
+			return item.Format(value), true
+		}
+	}
+	for _, item := range f {
+		if item.pattern == nil && item.Type != nil && item.Type == reflect.TypeOf(value) {
+			return item.Format(value), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,55 @@
+package differ
+
+// Invert returns the changes that undo the given changes: Before and After are swapped, added items become removed
+// and the other way around, and list items swap their before and after index. Applying the inverted changes with
+// Apply onto the after value rolls it back to the before value.
+//
+// The given changes are not modified.
+func Invert[K comparable](changes ChangeMap[K]) ChangeMap[K] {
+	if changes == nil {
+		return nil
+	}
+
+	inverted := make(ChangeMap[K], len(changes))
+	for k, field := range changes {
+		inverted[invertKey(k)] = invertField(field)
+	}
+	return inverted
+}
+
+func invertField(field *ChangeField) *ChangeField {
+	kind := field.Kind
+	switch kind {
+	case Added:
+		kind = Removed
+	case Removed:
+		kind = Added
+	}
+
+	return &ChangeField{
+		Key:        invertKey(field.Key),
+		Kind:       kind,
+		IsNew:      kind == Added,
+		IsChanged:  field.IsChanged,
+		Redacted:   field.Redacted,
+		Cycle:      field.Cycle,
+		Changes:    Invert(field.Changes),
+		Before:     field.After,
+		After:      field.Before,
+		BeforeType: field.AfterType,
+		AfterType:  field.BeforeType,
+		BeforeNil:  field.AfterNil,
+		AfterNil:   field.BeforeNil,
+		Err:        field.Err,
+		JSONName:   field.JSONName,
+		JSONEmpty:  field.JSONEmpty,
+	}
+}
+
+// invertKey swaps the before and after index of list items, other keys are returned as is.
+func invertKey[K comparable](key K) K {
+	if index, ok := any(key).(SliceIndex); ok {
+		return any(SliceIndex{Before: index.After, After: index.Before}).(K)
+	}
+	return key
+}
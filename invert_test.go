@@ -0,0 +1,84 @@
+package differ
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestInvert(t *testing.T) {
+	_, changes, err := Diff("map", map[string]int{"a": 1, "b": 2}, map[string]int{"a": 3, "c": 4})
+	assert.Nil(t, err)
+
+	assert.Equal(t, ChangeMap[string]{
+		"map": {
+			Key:       "map",
+			Kind:      Modified,
+			IsChanged: true,
+			Changes: ChangeMap[any]{
+				"a": {Key: "a", Kind: Modified, IsChanged: true, Before: 3, After: 1},
+				"b": {Key: "b", Kind: Added, IsNew: true, IsChanged: true, Before: nil, After: 2},
+				"c": {Key: "c", Kind: Removed, IsChanged: true, Before: 4, After: nil},
+			},
+		},
+	}, Invert(changes))
+
+	// Inverting twice gives back the original changes.
+	assert.Equal(t, changes, Invert(Invert(changes)))
+}
+
+func TestInvert_Apply(t *testing.T) {
+	one, two := 1, 2
+
+	type testRow struct {
+		name   string
+		before func() any
+		after  func() any
+	}
+
+	runRows := func(t *testing.T, rows []*testRow) {
+		for _, r := range rows {
+			t.Run(r.name, func(t *testing.T) {
+				_, changes, err := Diff("order", r.before(), r.after())
+				assert.Nil(t, err)
+
+				// Undo the changes on after, which should give back before.
+				target := r.after()
+				err = Apply(&target, Invert(changes))
+				assert.Nil(t, err)
+				assert.Equal(t, r.before(), target)
+			})
+		}
+	}
+
+	runRows(t, []*testRow{
+		{
+			name:   "struct fields",
+			before: func() any { return &testApplyOrder{Status: "new", Owner: &testAddress{Street: "Main"}} },
+			after:  func() any { return &testApplyOrder{Status: "paid"} },
+		},
+		{
+			name:   "maps",
+			before: func() any { return testApplyOrder{Meta: map[string]any{"a": 1, "gone": true}} },
+			after:  func() any { return testApplyOrder{Meta: map[string]any{"a": 2, "new": "x"}} },
+		},
+		{
+			name:   "lists",
+			before: func() any { return testApplyOrder{Tags: []string{"a", "b", "c", "d"}} },
+			after:  func() any { return testApplyOrder{Tags: []string{"x", "a", "c", "y"}} },
+		},
+		{
+			name:   "omitempty fields",
+			before: func() any { return testTagged{Email: "a", Nickname: "a"} },
+			after:  func() any { return testTagged{Email: "b", Manager: &testAddress{Street: "Main"}} },
+		},
+		{
+			name: "lists by key",
+			before: func() any {
+				return testApplyOrder{Lines: []testApplyLine{{"a", &one}, {"b", &one}, {"c", nil}, {"d", &one}}}
+			},
+			after: func() any {
+				return testApplyOrder{Lines: []testApplyLine{{"d", &one}, {"b", &two}, {"a", &one}, {"e", nil}}}
+			},
+		},
+	})
+}
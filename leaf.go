@@ -0,0 +1,108 @@
+package differ
+
+import (
+	"math/big"
+	"net"
+	"net/url"
+	"reflect"
+	"time"
+)
+
+// leaf is a type that is compared as a whole with its own notion of equality instead of being walked into. Most of
+// these are structs with unexported fields, looking inside them would report changes that mean nothing.
+type leaf struct {
+	equal func(before reflect.Value, after reflect.Value) bool
+	// value returns the value reported in the ChangeField. Types whose methods are on the pointer, like big.Int, are
+	// reported as a pointer to a copy so that they print and marshal as they normally do. It's nil for types that are
+	// reported as they are.
+	value func(v reflect.Value) any
+	// clone returns a copy of v that shares no memory with it, for Merge3. It's nil for types that are copied by value.
+	clone func(v reflect.Value) reflect.Value
+}
+
+// leaves are the standard library types that are compared as leaves. Durations, byte slices and byte arrays (like
+// UUIDs) are already leaves because of their kind.
+var leaves = map[reflect.Type]leaf{
+	// Equal ignores the monotonic clock reading and the location, two times are the same if they're the same instant.
+	reflect.TypeOf(time.Time{}): {
+		equal: func(before reflect.Value, after reflect.Value) bool {
+			return before.Interface().(time.Time).Equal(after.Interface().(time.Time))
+		},
+	},
+	reflect.TypeOf(big.Int{}): {
+		equal: func(before reflect.Value, after reflect.Value) bool {
+			return bigInt(before).Cmp(bigInt(after)) == 0
+		},
+		value: func(v reflect.Value) any {
+			return new(big.Int).Set(bigInt(v))
+		},
+		clone: func(v reflect.Value) reflect.Value {
+			return reflect.ValueOf(new(big.Int).Set(bigInt(v))).Elem()
+		},
+	},
+	reflect.TypeOf(big.Float{}): {
+		equal: func(before reflect.Value, after reflect.Value) bool {
+			return bigFloat(before).Cmp(bigFloat(after)) == 0
+		},
+		value: func(v reflect.Value) any {
+			return new(big.Float).Copy(bigFloat(v))
+		},
+		clone: func(v reflect.Value) reflect.Value {
+			return reflect.ValueOf(new(big.Float).Copy(bigFloat(v))).Elem()
+		},
+	},
+	// Equal treats an IPv4 address and its IPv6 form as the same address.
+	reflect.TypeOf(net.IP{}): {
+		equal: func(before reflect.Value, after reflect.Value) bool {
+			return before.Interface().(net.IP).Equal(after.Interface().(net.IP))
+		},
+		clone: func(v reflect.Value) reflect.Value {
+			return reflect.ValueOf(append(net.IP(nil), v.Interface().(net.IP)...))
+		},
+	},
+	reflect.TypeOf(url.URL{}): {
+		equal: func(before reflect.Value, after reflect.Value) bool {
+			return urlOf(before).String() == urlOf(after).String()
+		},
+		value: func(v reflect.Value) any {
+			u := *urlOf(v)
+			return &u
+		},
+	},
+}
+
+// identities are pointer types that point to an identity rather than to data, like the location of a time.Time,
+// which is compared by its address. They're shared instead of copied.
+var identities = map[reflect.Type]bool{
+	reflect.TypeOf((*time.Location)(nil)): true,
+}
+
+// diffLeaf returns the change between two values of a leaf type.
+func diffLeaf(o *options, key any, l leaf, before reflect.Value, after reflect.Value) *ChangeField {
+	var change *ChangeField
+	if l.equal(before, after) {
+		change = unchanged(o, key, before, after)
+	} else {
+		change = modified(key, before, after)
+	}
+	if change != nil && l.value != nil {
+		change.Before = l.value(before)
+		change.After = l.value(after)
+	}
+	return change
+}
+
+// bigInt returns the big.Int that v holds. Values given to the walker are always addressable, see readable.
+func bigInt(v reflect.Value) *big.Int {
+	return v.Addr().Interface().(*big.Int)
+}
+
+// bigFloat returns the big.Float that v holds.
+func bigFloat(v reflect.Value) *big.Float {
+	return v.Addr().Interface().(*big.Float)
+}
+
+// urlOf returns the url.URL that v holds.
+func urlOf(v reflect.Value) *url.URL {
+	return v.Addr().Interface().(*url.URL)
+}
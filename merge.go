@@ -0,0 +1,221 @@
+package differ
+
+import (
+	"reflect"
+)
+
+// Conflict is a path that was changed differently by both sides of Merge3. Ours and Theirs are the changes made by
+// each side at that path, relative to the base.
+type Conflict struct {
+	Path   Path
+	Ours   *ChangeField
+	Theirs *ChangeField
+}
+
+// Merge3 merges the changes made by ours and theirs on top of base, as when 2 users edit the same entity from the same
+// version. Changes made by only one side are applied, and changes that both sides made the same way are applied once.
+// When both sides changed the same path differently, the path is returned as a Conflict and keeps its base value in
+// merged. Conflict paths are relative to the merged value.
+//
+// Lists are merged item by item when both sides only changed values within the items. If both sides changed a list
+// and either side added, removed or moved items, the whole list is a conflict unless both sides made the same changes.
+//
+// Base is not modified, merged is a deep copy of base with the changes applied.
+func Merge3[T any](
+	base T,
+	ours T,
+	theirs T,
+) (
+	merged T,
+	conflicts []Conflict,
+	err error,
+) {
+	_, changesOurs, err := Diff("", base, ours)
+	if err != nil {
+		return merged, nil, err
+	}
+	_, changesTheirs, err := Diff("", base, theirs)
+	if err != nil {
+		return merged, nil, err
+	}
+
+	field, conflicts, err := mergeChanges(nil, changesOurs[""], changesTheirs[""])
+	if err != nil {
+		return merged, nil, err
+	}
+
+	merged = base
+	value := reflect.ValueOf(&merged).Elem()
+	value.Set(deepCopy(readable(value), make(map[uintptr]reflect.Value)))
+	if field != nil {
+		err = Apply(&merged, ChangeMap[string]{"": field})
+		if err != nil {
+			return merged, nil, err
+		}
+	}
+	return merged, conflicts, nil
+}
+
+// mergeChanges combines the changes made by both sides at the given path, returning the changes that can be applied
+// and the conflicts.
+func mergeChanges(
+	path Path,
+	ours *ChangeField,
+	theirs *ChangeField,
+) (
+	merged *ChangeField,
+	conflicts []Conflict,
+	err error,
+) {
+	if ours == nil {
+		return theirs, nil, nil
+	}
+	if theirs == nil {
+		return ours, nil, nil
+	}
+
+	same, err := sameChange(ours, theirs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if same {
+		return ours, nil, nil
+	}
+
+	// Changes within the same struct, map, or list can be merged key by key, as long as list items stay in place.
+	canMerge := len(ours.Changes) > 0 && len(theirs.Changes) > 0 && ours.Kind == theirs.Kind
+	if canMerge && isSliceChanges(ours.Changes) {
+		canMerge = itemsInPlace(ours.Changes) && itemsInPlace(theirs.Changes)
+	}
+	if canMerge == false {
+		return nil, []Conflict{{Path: path, Ours: ours, Theirs: theirs}}, nil
+	}
+
+	changes := make(ChangeMap[any])
+	for k, child := range ours.Changes {
+		changes[k] = child
+	}
+	for _, child := range sortedFields(theirs.Changes) {
+		childPath := appendPath(path, pathElement(child))
+		mergedChild, childConflicts, err := mergeChanges(childPath, changes[child.Key], child)
+		if err != nil {
+			return nil, nil, err
+		}
+		conflicts = append(conflicts, childConflicts...)
+		if mergedChild == nil {
+			delete(changes, child.Key)
+			continue
+		}
+		changes[child.Key] = mergedChild
+	}
+
+	if len(changes) == 0 {
+		return nil, conflicts, nil
+	}
+	merged = &ChangeField{}
+	*merged = *ours
+	merged.Changes = changes
+	return merged, conflicts, nil
+}
+
+// itemsInPlace returns true if the list changes only modify items, without adding, removing or moving any.
+func itemsInPlace(changes ChangeMap[any]) bool {
+	for k, field := range changes {
+		index := k.(SliceIndex)
+		if index.Before != index.After || field.Kind == Moved {
+			return false
+		}
+	}
+	return true
+}
+
+// sameChange returns true if both changes result in the same value.
+func sameChange(a *ChangeField, b *ChangeField) (bool, error) {
+	if a.Kind != b.Kind || a.Key != b.Key || len(a.Changes) != len(b.Changes) {
+		return false, nil
+	}
+
+	if len(a.Changes) == 0 {
+		change, err := diff(&options{}, nil, nil, readable(reflect.ValueOf(a.After)), readable(reflect.ValueOf(b.After)))
+		return change == nil, err
+	}
+	for k, childA := range a.Changes {
+		childB, ok := b.Changes[k]
+		if ok == false {
+			return false, nil
+		}
+		same, err := sameChange(childA, childB)
+		if err != nil || same == false {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// deepCopy returns a copy of v that shares no pointers, maps, or slices with v. Pointers that are referenced more than
+// once are copied once, the copies map holds the copy of each pointer. Leaves like time.Time are copied as a whole,
+// and pointers to identities like time.Location are kept as they are.
+func deepCopy(v reflect.Value, copies map[uintptr]reflect.Value) reflect.Value {
+	if l, ok := leaves[v.Type()]; ok {
+		if l.clone != nil {
+			return l.clone(v)
+		}
+		return v
+	}
+	if identities[v.Type()] {
+		return v
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		if copied, ok := copies[v.Pointer()]; ok {
+			return copied
+		}
+		copied := reflect.New(v.Type().Elem())
+		copies[v.Pointer()] = copied
+		copied.Elem().Set(deepCopy(readable(v.Elem()), copies))
+		return copied
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(deepCopy(readable(v.Elem()), copies))
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			readable(copied.Field(i)).Set(deepCopy(readable(v.Field(i)), copies))
+		}
+		return copied
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(iter.Key(), deepCopy(readable(iter.Value()), copies))
+		}
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(deepCopy(readable(v.Index(i)), copies))
+		}
+		return copied
+	case reflect.Array:
+		copied := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(deepCopy(readable(v.Index(i)), copies))
+		}
+		return copied
+	}
+	return v
+}
@@ -0,0 +1,143 @@
+package differ
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMerge3(t *testing.T) {
+	type profile struct {
+		Name  string
+		Email string
+		Tags  []string
+		Meta  map[string]int
+		Roles []testRenderRole
+	}
+
+	base := profile{
+		Name:  "Rick",
+		Email: "rick@example.com",
+		Tags:  []string{"a", "b", "c"},
+		Meta:  map[string]int{"x": 1, "y": 1},
+		Roles: []testRenderRole{{"admin"}},
+	}
+
+	t.Run("non-overlapping changes are merged", func(t *testing.T) {
+		ours := base
+		ours.Name = "Morty"
+		ours.Meta = map[string]int{"x": 2, "y": 1}
+		ours.Tags = []string{"a", "B", "c"}
+
+		theirs := base
+		theirs.Email = "morty@example.com"
+		theirs.Meta = map[string]int{"x": 1, "y": 1, "z": 3}
+		theirs.Tags = []string{"a", "b", "C"}
+
+		merged, conflicts, err := Merge3(base, ours, theirs)
+		assert.Nil(t, err)
+		assert.Empty(t, conflicts)
+		assert.Equal(t, profile{
+			Name:  "Morty",
+			Email: "morty@example.com",
+			Tags:  []string{"a", "B", "C"},
+			Meta:  map[string]int{"x": 2, "y": 1, "z": 3},
+			Roles: []testRenderRole{{"admin"}},
+		}, merged)
+
+		// Base is not modified.
+		assert.Equal(t, map[string]int{"x": 1, "y": 1}, base.Meta)
+	})
+
+	t.Run("same changes on both sides are applied once", func(t *testing.T) {
+		ours := base
+		ours.Roles = []testRenderRole{{"admin"}, {"billing"}}
+		theirs := base
+		theirs.Roles = []testRenderRole{{"admin"}, {"billing"}}
+
+		merged, conflicts, err := Merge3(base, ours, theirs)
+		assert.Nil(t, err)
+		assert.Empty(t, conflicts)
+		assert.Equal(t, ours, merged)
+	})
+
+	t.Run("conflicting changes keep the base value", func(t *testing.T) {
+		ours := base
+		ours.Name = "Morty"
+		ours.Tags = []string{"x", "a", "b", "c"}
+		ours.Meta = map[string]int{"x": 2, "y": 1}
+
+		theirs := base
+		theirs.Name = "Summer"
+		theirs.Tags = []string{"a", "b", "C"}
+		theirs.Meta = map[string]int{"x": 1, "y": 2}
+
+		merged, conflicts, err := Merge3(base, ours, theirs)
+		assert.Nil(t, err)
+		assert.Equal(t, profile{
+			Name:  "Rick",
+			Email: "rick@example.com",
+			Tags:  []string{"a", "b", "c"},
+			Meta:  map[string]int{"x": 2, "y": 2},
+			Roles: []testRenderRole{{"admin"}},
+		}, merged)
+
+		var paths []string
+		for _, conflict := range conflicts {
+			paths = append(paths, conflict.Path.String())
+		}
+		assert.ElementsMatch(t, []string{"Name", "Tags"}, paths)
+		for _, conflict := range conflicts {
+			if conflict.Path.String() == "Name" {
+				assert.Equal(t, "Rick", conflict.Ours.Before)
+				assert.Equal(t, "Morty", conflict.Ours.After)
+				assert.Equal(t, "Summer", conflict.Theirs.After)
+			}
+		}
+	})
+}
+
+func TestMerge3_Leaves(t *testing.T) {
+	type event struct {
+		Name   string
+		At     time.Time
+		Amount *big.Int
+	}
+	base := event{Name: "created", At: time.Now(), Amount: big.NewInt(100)}
+	ours := base
+	ours.Name = "paid"
+
+	merged, conflicts, err := Merge3(base, ours, base)
+	assert.Nil(t, err)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, "paid", merged.Name)
+
+	// The time keeps its location and monotonic clock reading, so it's still the same value.
+	assert.True(t, merged.At == base.At)
+	assert.Equal(t, base.At.String(), merged.At.String())
+
+	// Other leaves are copied, changing the merged value doesn't change base.
+	assert.NotSame(t, base.Amount, merged.Amount)
+	merged.Amount.Add(merged.Amount, big.NewInt(1))
+	assert.Equal(t, "100", base.Amount.String())
+}
+
+func TestDeepCopy(t *testing.T) {
+	type node struct {
+		Next  *node
+		items []int
+		meta  map[string]any
+	}
+	original := &node{items: []int{1}, meta: map[string]any{"a": []int{1}}}
+	original.Next = original
+
+	copied := deepCopy(readable(reflect.ValueOf(original)), make(map[uintptr]reflect.Value)).Interface().(*node)
+	assert.NotSame(t, original, copied)
+	assert.Same(t, copied, copied.Next)
+	copied.items[0] = 2
+	copied.meta["a"].([]int)[0] = 2
+	assert.Equal(t, []int{1}, original.items)
+	assert.Equal(t, []int{1}, original.meta["a"])
+}
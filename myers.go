@@ -0,0 +1,216 @@
+package differ
+
+import (
+	"fmt"
+)
+
+// editOp is a single step of an edit script.
+type editOp int
+
+const (
+	editMatch editOp = iota
+	editDelete
+	editInsert
+)
+
+// edit is a step of the edit script that turns the before list into the after list. The before and after index are
+// -1 when the step doesn't touch that side (insert has no before index, delete has no after index).
+type edit struct {
+	op     editOp
+	before int
+	after  int
+}
+
+// myers returns the shortest edit script that turns a list of n items into a list of m items, using the linear space
+// variation of the algorithm from Eugene W. Myers, "An O(ND) Difference Algorithm and Its Variations". The equal
+// function tells whether the item at index i of the before list equals the item at index j of the after list.
+//
+// The returned script contains every index of both lists exactly once, in order.
+func myers(n int, m int, equal func(i int, j int) (bool, error)) ([]edit, error) {
+	s := &myersScript{equal: equal}
+	if err := s.compare(0, n, 0, m); err != nil {
+		return nil, err
+	}
+	return s.edits, nil
+}
+
+// myersScript collects the edit script while myers splits the lists into smaller parts.
+type myersScript struct {
+	equal func(i int, j int) (bool, error)
+	edits []edit
+}
+
+// compare adds the edits that turn before[x0:x1] into after[y0:y1]. The part is split around its middle snake, the
+// diagonal in the middle of one of its shortest edit scripts, and both sides are compared on their own, so that only
+// the furthest reaching paths of the current round are kept in memory.
+func (s *myersScript) compare(x0 int, x1 int, y0 int, y1 int) error {
+	// Items that are the same at the start and at the end are matched right away.
+	for x0 < x1 && y0 < y1 {
+		eq, err := s.equal(x0, y0)
+		if err != nil {
+			return err
+		}
+		if eq == false {
+			break
+		}
+		s.edits = append(s.edits, edit{op: editMatch, before: x0, after: y0})
+		x0++
+		y0++
+	}
+	suffix := 0
+	for x0 < x1 && y0 < y1 {
+		eq, err := s.equal(x1-1, y1-1)
+		if err != nil {
+			return err
+		}
+		if eq == false {
+			break
+		}
+		x1--
+		y1--
+		suffix++
+	}
+
+	switch {
+	case x0 == x1:
+		for j := y0; j < y1; j++ {
+			s.edits = append(s.edits, edit{op: editInsert, before: -1, after: j})
+		}
+	case y0 == y1:
+		for i := x0; i < x1; i++ {
+			s.edits = append(s.edits, edit{op: editDelete, before: i, after: -1})
+		}
+	default:
+		// Both sides are left with items that differ at the start and at the end, so the script has at least 2
+		// edits and each side of the middle snake has at least 1, which makes both sides smaller than the whole.
+		sx, sy, ex, ey, err := s.middleSnake(x0, x1, y0, y1)
+		if err != nil {
+			return err
+		}
+		if err := s.compare(x0, sx, y0, sy); err != nil {
+			return err
+		}
+		for x, y := sx, sy; x < ex; x, y = x+1, y+1 {
+			s.edits = append(s.edits, edit{op: editMatch, before: x, after: y})
+		}
+		if err := s.compare(ex, x1, ey, y1); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < suffix; i++ {
+		s.edits = append(s.edits, edit{op: editMatch, before: x1 + i, after: y1 + i})
+	}
+	return nil
+}
+
+// middleSnake returns the start and the end of the middle snake of before[x0:x1] and after[y0:y1], found by
+// following the furthest reaching paths from the start and from the end at the same time until they overlap.
+//
+// Paths are tracked relative to the part. forward[k] is the furthest x reached on diagonal k = x - y from the start,
+// backward[k] is the furthest distance from the end reached on diagonal k counted from the end, and -1 marks a
+// diagonal that can only be reached by leaving the part.
+func (s *myersScript) middleSnake(
+	x0 int,
+	x1 int,
+	y0 int,
+	y1 int,
+) (
+	sx int,
+	sy int,
+	ex int,
+	ey int,
+	err error,
+) {
+	n, m := x1-x0, y1-y0
+	delta := n - m
+	odd := delta%2 != 0
+	max := (n + m + 1) / 2
+	offset := max + 1
+	forward := make([]int, 2*max+3)
+	backward := make([]int, 2*max+3)
+	for i := range forward {
+		forward[i] = -1
+		backward[i] = -1
+	}
+	forward[offset+1] = 0
+	backward[offset+1] = 0
+
+	for d := 0; d <= max; d++ {
+		for k := -d; k <= d; k += 2 {
+			x, y, ok := furthest(forward, offset, k, d, n, m)
+			if ok == false {
+				continue
+			}
+			startX, startY := x, y
+			for x < n && y < m {
+				eq, err := s.equal(x0+x, y0+y)
+				if err != nil {
+					return 0, 0, 0, 0, err
+				}
+				if eq == false {
+					break
+				}
+				x++
+				y++
+			}
+			forward[offset+k] = x
+
+			// The backward paths of the previous round overlap this one on the same diagonal.
+			if back := delta - k; odd && back >= -(d-1) && back <= d-1 && backward[offset+back] >= 0 {
+				if x >= n-backward[offset+back] {
+					return x0 + startX, y0 + startY, x0 + x, y0 + y, nil
+				}
+			}
+		}
+
+		for k := -d; k <= d; k += 2 {
+			x, y, ok := furthest(backward, offset, k, d, n, m)
+			if ok == false {
+				continue
+			}
+			startX, startY := x, y
+			for x < n && y < m {
+				eq, err := s.equal(x1-x-1, y1-y-1)
+				if err != nil {
+					return 0, 0, 0, 0, err
+				}
+				if eq == false {
+					break
+				}
+				x++
+				y++
+			}
+			backward[offset+k] = x
+
+			// The forward paths of this round overlap this one on the same diagonal.
+			if front := delta - k; odd == false && front >= -d && front <= d && forward[offset+front] >= 0 {
+				if forward[offset+front] >= n-x {
+					return x1 - x, y1 - y, x1 - startX, y1 - startY, nil
+				}
+			}
+		}
+	}
+
+	// Unreachable, the paths always overlap by the time each has gone half of the longest script.
+	return 0, 0, 0, 0, fmt.Errorf("myers: no middle snake between %d and %d items", n, m)
+}
+
+// furthest returns the point where a path of d edits on diagonal k starts its snake, extending the furthest path of
+// the previous round by one insert or delete. It returns false if there's no such path inside the n by m part.
+func furthest(v []int, offset int, k int, d int, n int, m int) (x int, y int, ok bool) {
+	if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+		x = v[offset+k+1]
+	} else {
+		x = v[offset+k-1]
+		if x >= 0 {
+			x++
+		}
+	}
+	y = x - k
+	if x < 0 || x > n || y > m {
+		v[offset+k] = -1
+		return 0, 0, false
+	}
+	return x, y, true
+}
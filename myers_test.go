@@ -0,0 +1,89 @@
+package differ
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"runtime"
+	"testing"
+)
+
+func TestMyers(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		before := make([]int, random.Intn(12))
+		after := make([]int, random.Intn(12))
+		for j := range before {
+			before[j] = random.Intn(4)
+		}
+		for j := range after {
+			after[j] = random.Intn(4)
+		}
+
+		edits, err := myers(len(before), len(after), func(i int, j int) (bool, error) {
+			return before[i] == after[j], nil
+		})
+		assert.Nil(t, err)
+
+		// The script visits every index of both lists in order, and matches only equal items.
+		x, y, matches := 0, 0, 0
+		for _, e := range edits {
+			switch e.op {
+			case editMatch:
+				assert.Equal(t, x, e.before)
+				assert.Equal(t, y, e.after)
+				assert.Equal(t, before[x], after[y])
+				x, y, matches = x+1, y+1, matches+1
+			case editDelete:
+				assert.Equal(t, x, e.before)
+				x++
+			case editInsert:
+				assert.Equal(t, y, e.after)
+				y++
+			}
+		}
+		assert.Equal(t, len(before), x)
+		assert.Equal(t, len(after), y)
+
+		// The script is the shortest when it matches as many items as the longest common subsequence.
+		assert.Equal(t, longestCommon(before, after), matches, "%v %v", before, after)
+	}
+}
+
+func TestMyers_Large(t *testing.T) {
+	before := make([]int, 4000)
+	after := make([]int, 4000)
+	for i := range before {
+		before[i] = i
+		after[i] = -i - 1
+	}
+
+	var start, end runtime.MemStats
+	runtime.ReadMemStats(&start)
+	edits, err := myers(len(before), len(after), func(i int, j int) (bool, error) {
+		return before[i] == after[j], nil
+	})
+	runtime.ReadMemStats(&end)
+	assert.Nil(t, err)
+	assert.Len(t, edits, 8000)
+
+	// Only the paths of the current round are kept, memory grows with the length of the lists, not its square.
+	assert.Less(t, end.TotalAlloc-start.TotalAlloc, uint64(16<<20))
+}
+
+// longestCommon returns the length of the longest common subsequence of a and b.
+func longestCommon(a []int, b []int) int {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+	return lengths[0][0]
+}
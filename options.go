@@ -0,0 +1,284 @@
+package differ
+
+import (
+	"math"
+	"reflect"
+)
+
+// Option configures Diff and DiffSlice.
+type Option func(*options)
+
+// SliceMatching is the strategy used to match the items of 2 lists.
+type SliceMatching int
+
+const (
+	// MatchSequence matches items using the shortest edit script between the 2 lists, so an item inserted at the top
+	// is reported as 1 new item. This is the default.
+	MatchSequence SliceMatching = iota
+	// MatchIndex matches items by their index, so an item inserted at the top is reported as every item changed, and
+	// 1 new item at the bottom.
+	MatchIndex
+)
+
+// options holds the configuration of a single Diff call. It's passed along to every function that walks the values,
+// so that a new option doesn't change their signature.
+type options struct {
+	unchanged      bool
+	maxDepth       int
+	ignoredPaths   []pathPattern
+	floatTolerance float64
+	floatRelative  float64
+	nanEqual       bool
+	nilChanges     map[NilKind]bool
+	strictTypes    bool
+	cycleError     bool
+	maxDepthError  bool
+	collectErrors  bool
+	sliceMatching  SliceMatching
+	sliceKey       func(elem any) any
+	comparators    map[reflect.Type]func(a any, b any) bool
+	redactedPaths  []pathPattern
+	redactionSalt  []byte
+
+	// err is the first invalid option, it's returned by Diff.
+	err error
+	// visiting holds the values that are being diffed on the current path, to detect cycles.
+	visiting map[visited]bool
+}
+
+func newOptions(opts []Option) (*options, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.err != nil {
+		return nil, o.err
+	}
+	return o, nil
+}
+
+// WithUnchanged includes the fields that have not changed in the ChangeMap, with Kind Unchanged. Structs, maps, and
+// lists that have not changed are Unchanged too, with all their fields in Changes. Values that refer back to a value
+// being diffed higher up the path are included as Unchanged with Cycle set, they're left out otherwise.
+func WithUnchanged() Option {
+	return func(o *options) {
+		o.unchanged = true
+	}
+}
+
+// WithMaxDepth stops Diff from looking inside structs, maps, and lists deeper than the given depth. The value given
+// to Diff is at depth 0, its fields at depth 1, and so on. A struct, map or list at the max depth that has changed is
+// reported as a whole, with Before and After set to the values. Zero means there's no limit.
+func WithMaxDepth(depth int) Option {
+	return func(o *options) {
+		o.maxDepth = depth
+	}
+}
+
+// WithIgnoredPaths skips the values at the given paths, the whole subtree of a skipped struct, map or list is not
+// looked at. Paths are in dotted notation and relative to the value given to Diff, without the key, for example
+// "metadata.resourceVersion" or "items[2].etag".
+//
+// Paths can have wildcards: "*" matches any struct field, map key or list index at that position, like "*.updatedAt"
+// or "items[*].etag", and "**" matches any number of elements, like "**.resourceVersion". Diff returns error if a path
+// can't be parsed.
+func WithIgnoredPaths(paths ...string) Option {
+	return func(o *options) {
+		for _, path := range paths {
+			pattern, err := parsePathPattern(path)
+			if err != nil {
+				if o.err == nil {
+					o.err = err
+				}
+				continue
+			}
+			o.ignoredPaths = append(o.ignoredPaths, pattern)
+		}
+	}
+}
+
+// WithFloatTolerance treats floats as the same when the absolute difference between them is at most tolerance. It
+// applies to the real and imaginary parts of complex numbers too.
+func WithFloatTolerance(tolerance float64) Option {
+	return func(o *options) {
+		o.floatTolerance = tolerance
+	}
+}
+
+// WithFloatRelativeTolerance treats floats as the same when the difference between them is at most tolerance times
+// the larger of their absolute values, for example 1e-9 ignores rounding noise whatever the magnitude of the values.
+// When both WithFloatTolerance and WithFloatRelativeTolerance are given, floats are the same if either is satisfied.
+func WithFloatRelativeTolerance(tolerance float64) Option {
+	return func(o *options) {
+		o.floatRelative = tolerance
+	}
+}
+
+// WithNaNEqual treats NaN as equal to NaN. By default NaN is never equal to anything, like the == operator, so a NaN
+// field is always reported as changed.
+func WithNaNEqual() Option {
+	return func(o *options) {
+		o.nanEqual = true
+	}
+}
+
+// WithSliceMatching sets the strategy used to match list items. Lists of structs that have a field tagged with
+// `differ:"key"` are always matched by that field.
+func WithSliceMatching(matching SliceMatching) Option {
+	return func(o *options) {
+		o.sliceMatching = matching
+	}
+}
+
+// WithSliceKey matches list items by the identity returned by keyOf, which is called with each list item and must
+// return a comparable value. The function is called for every list, it can return nil for items it doesn't know: a
+// list that has an item without identity is matched with the strategy set by WithSliceMatching instead.
+//
+// Items with the same identity on both sides are diffed with each other. Items whose identity only exist in before
+// are recorded as removed, and items whose identity only exist in after are recorded as new. Items that keep their
+// identity but are reordered relative to the other items are recorded as Moved.
+func WithSliceKey(keyOf func(elem any) any) Option {
+	return func(o *options) {
+		o.sliceKey = keyOf
+	}
+}
+
+// WithNilChanges reports a change between a nil of one of the given kinds and an empty value or another kind of nil,
+// which are the same by default. For example, WithNilChanges(NilSlice) reports a nil slice that becomes an empty
+// slice, and WithNilChanges(NilPointer) reports a nil *Address in an interface field that becomes a nil interface.
+func WithNilChanges(kinds ...NilKind) Option {
+	return func(o *options) {
+		if o.nilChanges == nil {
+			o.nilChanges = make(map[NilKind]bool)
+		}
+		for _, kind := range kinds {
+			o.nilChanges[kind] = true
+		}
+	}
+}
+
+// WithStrictTypes makes Diff return an error wrapping ErrNotTheSameType when a value changes to a different type,
+// instead of reporting it as TypeChanged. The error says at which path the types differ.
+func WithStrictTypes() Option {
+	return func(o *options) {
+		o.strictTypes = true
+	}
+}
+
+// WithCycleError makes Diff return an error wrapping ErrCycle when a value refers back to a value that is being diffed
+// higher up the path. Without it, such a value is skipped without a trace, or reported as a Cycle with WithUnchanged.
+func WithCycleError() Option {
+	return func(o *options) {
+		o.cycleError = true
+	}
+}
+
+// WithMaxDepthError makes Diff return an error wrapping ErrMaxDepth when a struct, map or list at the depth given to
+// WithMaxDepth has changed, instead of reporting it as a whole.
+func WithMaxDepthError() Option {
+	return func(o *options) {
+		o.maxDepthError = true
+	}
+}
+
+// WithCollectErrors keeps diffing when a value can't be diffed, like a chan or func field. The value is recorded in the
+// changes with Kind Failed and the error in ChangeField.Err, and Diff returns the changes along with the errors of
+// every failed value joined with errors.Join.
+func WithCollectErrors() Option {
+	return func(o *options) {
+		o.collectErrors = true
+	}
+}
+
+// WithComparator compares values of type t with equal instead of looking inside them, for types whose equality is
+// not the equality of their fields, like an email that is not normalized. Values that are not equal are reported as
+// a whole, with Before and After set to the values.
+//
+// The type is matched after pointers and interfaces are resolved, so a comparator for T is used for *T too. A
+// comparator takes precedence over the Differ interface and over the built in comparison of types like time.Time.
+func WithComparator(t reflect.Type, equal func(a any, b any) bool) Option {
+	return func(o *options) {
+		if o.comparators == nil {
+			o.comparators = make(map[reflect.Type]func(a any, b any) bool)
+		}
+		o.comparators[t] = equal
+	}
+}
+
+// WithRedactedPaths masks the values at the given paths, for passwords, tokens, or personal data that must not be
+// written into audit logs. A redacted value that has changed is still reported, but its Before and After are replaced
+// by RedactedMask, or by a salted hash with WithRedactionHash, and the changes within it are not reported. The
+// ChangeField is marked as Redacted. Struct fields can be redacted with the `differ:"redact"` tag too.
+//
+// Paths are written like the paths of WithIgnoredPaths, and can have the same wildcards.
+func WithRedactedPaths(paths ...string) Option {
+	return func(o *options) {
+		for _, path := range paths {
+			pattern, err := parsePathPattern(path)
+			if err != nil {
+				if o.err == nil {
+					o.err = err
+				}
+				continue
+			}
+			o.redactedPaths = append(o.redactedPaths, pattern)
+		}
+	}
+}
+
+// WithRedactionHash replaces redacted values with an HMAC-SHA256 of the value keyed by salt, instead of RedactedMask.
+// The same value gives the same hash, so changes to a redacted value can be correlated without revealing it. Keep the
+// salt secret, short values like PINs can be found by hashing every possible value otherwise.
+func WithRedactionHash(salt []byte) Option {
+	return func(o *options) {
+		o.redactionSalt = salt
+	}
+}
+
+// ignores returns true if the value at the given path must be skipped. The first element of the path is the key given
+// to Diff, which is not part of the ignored paths.
+func (o *options) ignores(path Path) bool {
+	if len(o.ignoredPaths) == 0 || len(path) < 2 {
+		return false
+	}
+	for _, pattern := range o.ignoredPaths {
+		if pattern.match(path[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// redacts returns true if the value at the given path must be masked.
+func (o *options) redacts(path Path) bool {
+	if len(o.redactedPaths) == 0 || len(path) < 2 {
+		return false
+	}
+	for _, pattern := range o.redactedPaths {
+		if pattern.match(path[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// atMaxDepth returns true if the value at the given path must not be looked into.
+func (o *options) atMaxDepth(path Path) bool {
+	return o.maxDepth > 0 && len(path)-1 >= o.maxDepth
+}
+
+// equalFloat compares 2 floats with the configured tolerance.
+func (o *options) equalFloat(a float64, b float64) bool {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return o.nanEqual && math.IsNaN(a) && math.IsNaN(b)
+	}
+	if a == b {
+		return true
+	}
+	if math.IsInf(a, 0) || math.IsInf(b, 0) {
+		// An infinity is only equal to itself, no tolerance reaches it.
+		return false
+	}
+	diff := math.Abs(a - b)
+	return diff <= o.floatTolerance || diff <= o.floatRelative*math.Max(math.Abs(a), math.Abs(b))
+}
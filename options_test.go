@@ -0,0 +1,494 @@
+package differ
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testEmail string
+
+func TestOptions(t *testing.T) {
+	type item struct {
+		ID   string
+		Name string
+	}
+
+	type testRow struct {
+		name   string
+		before any
+		after  any
+		opts   []Option
+
+		expectError      bool
+		expectHasChanges bool
+		expectChanges    *ChangeField
+	}
+
+	runRows := func(t *testing.T, rows []*testRow) {
+		for _, r := range rows {
+			t.Run(r.name, func(t *testing.T) {
+				hasChanges, changes, err := Diff("root", r.before, r.after, r.opts...)
+				if r.expectError {
+					assert.NotNil(t, err)
+					return
+				}
+				assert.Nil(t, err)
+				assert.Equal(t, r.expectHasChanges, hasChanges)
+				assert.Equal(t, r.expectChanges, changes["root"])
+			})
+		}
+	}
+
+	runRows(t, []*testRow{
+		{
+			name:             "unchanged leaf",
+			before:           "a",
+			after:            "a",
+			opts:             []Option{WithUnchanged()},
+			expectHasChanges: false,
+			expectChanges:    &ChangeField{Key: "root", Kind: Unchanged, Before: "a", After: "a"},
+		},
+		{
+			name:             "unchanged fields are included",
+			before:           map[string]int{"a": 1, "b": 2},
+			after:            map[string]int{"a": 1, "b": 3},
+			opts:             []Option{WithUnchanged()},
+			expectHasChanges: true,
+			expectChanges: &ChangeField{
+				Key:       "root",
+				Kind:      Modified,
+				IsChanged: true,
+				Changes: ChangeMap[any]{
+					"a": {Key: "a", Kind: Unchanged, Before: 1, After: 1},
+					"b": {Key: "b", Kind: Modified, IsChanged: true, Before: 2, After: 3},
+				},
+			},
+		},
+		{
+			name:             "unchanged list items are included",
+			before:           []string{"a", "b"},
+			after:            []string{"a", "c"},
+			opts:             []Option{WithUnchanged()},
+			expectHasChanges: true,
+			expectChanges: &ChangeField{
+				Key:       "root",
+				Kind:      Modified,
+				IsChanged: true,
+				Changes: ChangeMap[any]{
+					SliceIndex{0, 0}: {Key: SliceIndex{0, 0}, Kind: Unchanged, Before: "a", After: "a"},
+					SliceIndex{1, 1}: {Key: SliceIndex{1, 1}, Kind: Modified, IsChanged: true, Before: "b", After: "c"},
+				},
+			},
+		},
+		{
+			name:             "max depth reports the value as a whole",
+			before:           map[string][]int{"a": {1, 2}, "b": {3}},
+			after:            map[string][]int{"a": {1, 3}, "b": {3}},
+			opts:             []Option{WithMaxDepth(1)},
+			expectHasChanges: true,
+			expectChanges: &ChangeField{
+				Key:       "root",
+				Kind:      Modified,
+				IsChanged: true,
+				Changes: ChangeMap[any]{
+					"a": {Key: "a", Kind: Modified, IsChanged: true, Before: []int{1, 2}, After: []int{1, 3}},
+				},
+			},
+		},
+		{
+			name: "max depth with ignored paths",
+			before: map[string]map[string]int{
+				"a": {"version": 1, "value": 1},
+				"b": {"version": 1, "value": 1},
+			},
+			after: map[string]map[string]int{
+				"a": {"version": 2, "value": 1},
+				"b": {"version": 2, "value": 2},
+			},
+			opts:             []Option{WithMaxDepth(1), WithIgnoredPaths("*.version")},
+			expectHasChanges: true,
+			expectChanges: &ChangeField{
+				Key:       "root",
+				Kind:      Modified,
+				IsChanged: true,
+				Changes: ChangeMap[any]{
+					"b": {
+						Key:       "b",
+						Kind:      Modified,
+						IsChanged: true,
+						Before:    map[string]int{"version": 1, "value": 1},
+						After:     map[string]int{"version": 2, "value": 2},
+					},
+				},
+			},
+		},
+		{
+			name:             "ignored paths",
+			before:           map[string]any{"a": 1, "b": map[string]int{"c": 1, "d": 1}, "e": []int{1, 2}},
+			after:            map[string]any{"a": 2, "b": map[string]int{"c": 2, "d": 2}, "e": []int{1, 3}, "f": 1},
+			opts:             []Option{WithIgnoredPaths("a", "b.c", "e[1]", "f")},
+			expectHasChanges: true,
+			expectChanges: &ChangeField{
+				Key:       "root",
+				Kind:      Modified,
+				IsChanged: true,
+				Changes: ChangeMap[any]{
+					"b": {
+						Key:       "b",
+						Kind:      Modified,
+						IsChanged: true,
+						Changes: ChangeMap[any]{
+							"d": {Key: "d", Kind: Modified, IsChanged: true, Before: 1, After: 2},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:             "ignored path patterns",
+			before:           map[string]any{"a": map[string]any{"metadata": map[string]any{"resourceVersion": 1, "name": "x"}}, "b": []item{{ID: "1"}}},
+			after:            map[string]any{"a": map[string]any{"metadata": map[string]any{"resourceVersion": 2, "name": "y"}}, "b": []item{{ID: "2"}}},
+			opts:             []Option{WithIgnoredPaths("**.resourceVersion", "b[*].ID")},
+			expectHasChanges: true,
+			expectChanges: &ChangeField{
+				Key:       "root",
+				Kind:      Modified,
+				IsChanged: true,
+				Changes: ChangeMap[any]{
+					"a": {
+						Key:       "a",
+						Kind:      Modified,
+						IsChanged: true,
+						Changes: ChangeMap[any]{
+							"metadata": {
+								Key:       "metadata",
+								Kind:      Modified,
+								IsChanged: true,
+								Changes: ChangeMap[any]{
+									"name": {Key: "name", Kind: Modified, IsChanged: true, Before: "x", After: "y"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:             "float within tolerance",
+			before:           []float64{1.0, 2.0},
+			after:            []float64{1.0005, 2.5},
+			opts:             []Option{WithFloatTolerance(0.001)},
+			expectHasChanges: true,
+			expectChanges: &ChangeField{
+				Key:       "root",
+				Kind:      Modified,
+				IsChanged: true,
+				Changes: ChangeMap[any]{
+					SliceIndex{1, 1}: {Key: SliceIndex{1, 1}, Kind: Modified, IsChanged: true, Before: 2.0, After: 2.5},
+				},
+			},
+		},
+		{
+			name:             "float within relative tolerance",
+			before:           []float64{1e9, 1},
+			after:            []float64{1e9 + 0.5, 1.5},
+			opts:             []Option{WithFloatRelativeTolerance(1e-6)},
+			expectHasChanges: true,
+			expectChanges: &ChangeField{
+				Key:       "root",
+				Kind:      Modified,
+				IsChanged: true,
+				Changes: ChangeMap[any]{
+					SliceIndex{1, 1}: {Key: SliceIndex{1, 1}, Kind: Modified, IsChanged: true, Before: 1.0, After: 1.5},
+				},
+			},
+		},
+		{
+			name:             "infinity outside relative tolerance",
+			before:           map[string]float64{"a": 1, "b": math.Inf(-1)},
+			after:            map[string]float64{"a": math.Inf(1), "b": math.Inf(1)},
+			opts:             []Option{WithFloatRelativeTolerance(1e-9)},
+			expectHasChanges: true,
+			expectChanges: &ChangeField{
+				Key:       "root",
+				Kind:      Modified,
+				IsChanged: true,
+				Changes: ChangeMap[any]{
+					"a": {Key: "a", Kind: Modified, IsChanged: true, Before: 1.0, After: math.Inf(1)},
+					"b": {Key: "b", Kind: Modified, IsChanged: true, Before: math.Inf(-1), After: math.Inf(1)},
+				},
+			},
+		},
+		{
+			name:             "complex within tolerance",
+			before:           complex(1, 1),
+			after:            complex(1.0005, 0.9995),
+			opts:             []Option{WithFloatTolerance(0.001)},
+			expectHasChanges: false,
+		},
+		{
+			name:             "NaN equal",
+			before:           map[string]float64{"a": math.NaN(), "b": math.Inf(1)},
+			after:            map[string]float64{"a": math.NaN(), "b": math.Inf(1)},
+			opts:             []Option{WithNaNEqual(), WithFloatRelativeTolerance(0.1)},
+			expectHasChanges: false,
+		},
+		{
+			name:             "match by index",
+			before:           []string{"a", "b"},
+			after:            []string{"x", "a", "b"},
+			opts:             []Option{WithSliceMatching(MatchIndex)},
+			expectHasChanges: true,
+			expectChanges: &ChangeField{
+				Key:       "root",
+				Kind:      Modified,
+				IsChanged: true,
+				Changes: ChangeMap[any]{
+					SliceIndex{0, 0}:  {Key: SliceIndex{0, 0}, Kind: Modified, IsChanged: true, Before: "a", After: "x"},
+					SliceIndex{1, 1}:  {Key: SliceIndex{1, 1}, Kind: Modified, IsChanged: true, Before: "b", After: "a"},
+					SliceIndex{-1, 2}: {Key: SliceIndex{-1, 2}, Kind: Added, IsNew: true, IsChanged: true, After: "b"},
+				},
+			},
+		},
+		{
+			name:   "match by slice key",
+			before: []item{{ID: "a", Name: "A"}, {ID: "b", Name: "B"}},
+			after:  []item{{ID: "b", Name: "B2"}, {ID: "a", Name: "A"}},
+			opts: []Option{WithSliceKey(func(elem any) any {
+				if i, ok := elem.(item); ok {
+					return i.ID
+				}
+				return nil
+			})},
+			expectHasChanges: true,
+			expectChanges: &ChangeField{
+				Key:       "root",
+				Kind:      Modified,
+				IsChanged: true,
+				Changes: ChangeMap[any]{
+					SliceIndex{0, 1}: {
+						Key:       SliceIndex{0, 1},
+						Kind:      Moved,
+						IsChanged: true,
+						Before:    item{ID: "a", Name: "A"},
+						After:     item{ID: "a", Name: "A"},
+					},
+					SliceIndex{1, 0}: {
+						Key:       SliceIndex{1, 0},
+						Kind:      Modified,
+						IsChanged: true,
+						Changes: ChangeMap[any]{
+							"Name": {Key: "Name", Kind: Modified, IsChanged: true, Before: "B", After: "B2"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:   "slice key without identity falls back",
+			before: []string{"a", "b"},
+			after:  []string{"x", "a", "b"},
+			opts: []Option{WithSliceKey(func(elem any) any {
+				return nil
+			})},
+			expectHasChanges: true,
+			expectChanges: &ChangeField{
+				Key:       "root",
+				Kind:      Modified,
+				IsChanged: true,
+				Changes: ChangeMap[any]{
+					SliceIndex{-1, 0}: {Key: SliceIndex{-1, 0}, Kind: Added, IsNew: true, IsChanged: true, After: "x"},
+				},
+			},
+		},
+		{
+			name:        "invalid ignored path",
+			before:      1,
+			after:       2,
+			opts:        []Option{WithIgnoredPaths("items[1")},
+			expectError: true,
+		},
+		{
+			name:   "comparator",
+			before: map[string]any{"email": testEmail("Rick@Example.com"), "at": time.Date(2024, 1, 1, 7, 0, 0, 0, time.FixedZone("WIB", 7*3600))},
+			after:  map[string]any{"email": testEmail("rick@example.com"), "at": time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			opts: []Option{
+				WithComparator(reflect.TypeOf(testEmail("")), func(a any, b any) bool {
+					return strings.EqualFold(string(a.(testEmail)), string(b.(testEmail)))
+				}),
+				WithComparator(reflect.TypeOf(time.Time{}), func(a any, b any) bool {
+					return a.(time.Time).Equal(b.(time.Time))
+				}),
+			},
+			expectHasChanges: false,
+		},
+		{
+			name:   "comparator not equal",
+			before: testEmail("rick@example.com"),
+			after:  testEmail("rick@example.org"),
+			opts: []Option{
+				WithComparator(reflect.TypeOf(testEmail("")), func(a any, b any) bool {
+					return strings.EqualFold(string(a.(testEmail)), string(b.(testEmail)))
+				}),
+			},
+			expectHasChanges: true,
+			expectChanges: &ChangeField{
+				Key:       "root",
+				Kind:      Modified,
+				IsChanged: true,
+				Before:    testEmail("rick@example.com"),
+				After:     testEmail("rick@example.org"),
+			},
+		},
+	})
+}
+
+func TestOptions_NaN(t *testing.T) {
+	// NaN is never equal to itself, so it can't be compared with assert.Equal.
+	hasChanges, changes, err := Diff("root", math.NaN(), math.NaN())
+	assert.Nil(t, err)
+	assert.True(t, hasChanges)
+	assert.Equal(t, Modified, changes["root"].Kind)
+	assert.True(t, math.IsNaN(changes["root"].Before.(float64)))
+	assert.True(t, math.IsNaN(changes["root"].After.(float64)))
+
+	hasChanges, _, err = Diff("root", math.NaN(), 1.0, WithNaNEqual())
+	assert.Nil(t, err)
+	assert.True(t, hasChanges)
+}
+
+func TestOptions_Nil(t *testing.T) {
+	type holder struct {
+		Value   any
+		Tags    []string
+		Labels  map[string]string
+		Address *testAddress
+	}
+
+	type testRow struct {
+		name   string
+		before any
+		after  any
+		opts   []Option
+
+		expectHasChanges bool
+		expectChanges    ChangeMap[any]
+	}
+
+	runRows := func(t *testing.T, rows []*testRow) {
+		for _, r := range rows {
+			t.Run(r.name, func(t *testing.T) {
+				hasChanges, changes, err := Diff("root", r.before, r.after, r.opts...)
+				assert.Nil(t, err)
+				assert.Equal(t, r.expectHasChanges, hasChanges)
+				if r.expectHasChanges {
+					assert.Equal(t, r.expectChanges, changes["root"].Changes)
+				}
+			})
+		}
+	}
+
+	runRows(t, []*testRow{
+		{
+			name:             "nil and empty are the same by default",
+			before:           holder{Value: nil, Tags: nil, Labels: nil},
+			after:            holder{Value: (*testAddress)(nil), Tags: []string{}, Labels: map[string]string{}},
+			expectHasChanges: false,
+		},
+		{
+			name:             "nil pointer",
+			before:           holder{Value: nil},
+			after:            holder{Value: (*testAddress)(nil)},
+			opts:             []Option{WithNilChanges(NilPointer)},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[any]{
+				"Value": {
+					Key:       "Value",
+					Kind:      Modified,
+					IsChanged: true,
+					BeforeNil: UntypedNil,
+					AfterNil:  NilPointer,
+				},
+			},
+		},
+		{
+			name:             "nil slice and map",
+			before:           holder{Tags: nil, Labels: map[string]string{}},
+			after:            holder{Tags: []string{}, Labels: nil},
+			opts:             []Option{WithNilChanges(NilSlice, NilMap)},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[any]{
+				"Tags": {
+					Key:       "Tags",
+					Kind:      Modified,
+					IsChanged: true,
+					Before:    []string(nil),
+					After:     []string{},
+					BeforeNil: NilSlice,
+				},
+				"Labels": {
+					Key:       "Labels",
+					Kind:      Modified,
+					IsChanged: true,
+					Before:    map[string]string{},
+					After:     map[string]string(nil),
+					AfterNil:  NilMap,
+				},
+			},
+		},
+		{
+			name:             "only the given kinds",
+			before:           holder{Tags: nil, Labels: map[string]string{}},
+			after:            holder{Tags: []string{}, Labels: nil},
+			opts:             []Option{WithNilChanges(NilMap)},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[any]{
+				"Labels": {
+					Key:       "Labels",
+					Kind:      Modified,
+					IsChanged: true,
+					Before:    map[string]string{},
+					After:     map[string]string(nil),
+					AfterNil:  NilMap,
+				},
+			},
+		},
+		{
+			name:             "nil slice that gets items",
+			before:           holder{Tags: nil},
+			after:            holder{Tags: []string{"a"}},
+			opts:             []Option{WithNilChanges(NilSlice)},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[any]{
+				"Tags": {
+					Key:       "Tags",
+					Kind:      Modified,
+					IsChanged: true,
+					BeforeNil: NilSlice,
+					Changes: ChangeMap[any]{
+						SliceIndex{-1, 0}: {Key: SliceIndex{-1, 0}, Kind: Added, IsNew: true, IsChanged: true, After: "a"},
+					},
+				},
+			},
+		},
+		{
+			name:             "nil pointer is marked",
+			before:           holder{Address: nil},
+			after:            holder{Address: &testAddress{Street: "Main"}},
+			expectHasChanges: true,
+			expectChanges: ChangeMap[any]{
+				"Address": {
+					Key:       "Address",
+					Kind:      Modified,
+					IsChanged: true,
+					After:     testAddress{Street: "Main"},
+					BeforeNil: NilPointer,
+				},
+			},
+		},
+	})
+}
@@ -0,0 +1,279 @@
+package differ
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// PatchOperation is a single RFC 6902 JSON Patch operation.
+type PatchOperation struct {
+	Op    string
+	Path  string
+	From  string
+	Value any
+}
+
+// MarshalJSON writes the operation with only the members that are valid for its op, so that a nil Value is still
+// written as null for add and replace.
+func (o PatchOperation) MarshalJSON() ([]byte, error) {
+	type operation struct {
+		Op   string `json:"op"`
+		Path string `json:"path"`
+		From string `json:"from,omitempty"`
+	}
+	type valueOperation struct {
+		Op    string `json:"op"`
+		Path  string `json:"path"`
+		Value any    `json:"value"`
+	}
+
+	switch o.Op {
+	case "add", "replace", "test":
+		return json.Marshal(valueOperation{Op: o.Op, Path: o.Path, Value: o.Value})
+	}
+	return json.Marshal(operation{Op: o.Op, Path: o.Path, From: o.From})
+}
+
+// JSONPatch returns the changes as RFC 6902 JSON Patch operations that turn the before value into the after value.
+// Paths are relative to the diffed value, the key given to Diff is not part of the path. Struct fields are named
+// after their json tag, even when the differ tag gives them another name, so the patch applies to the JSON encoding
+// of the value. Changes to unexported fields are left out, since they're not part of the JSON encoding.
+//
+// Changed values are replaced, new map keys and list items are added, removed ones are removed, and list items that
+// are moved are moved. Operations on a list are ordered so that each index refers to the list as it is after the
+// previous operations.
+//
+// Redacted values that would be added or replaced return an error, since the patch would write the mask over the
+// real value. Redacted values can still be removed or moved.
+func JSONPatch[K comparable](changes ChangeMap[K]) ([]PatchOperation, error) {
+	var ops []PatchOperation
+	for _, field := range sortedFields(changes) {
+		var err error
+		ops, err = appendPatch(ops, nil, field)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ops, nil
+}
+
+// appendPatch appends the operations for the given change, which is located at path.
+func appendPatch(ops []PatchOperation, path Path, field *ChangeField) ([]PatchOperation, error) {
+	pointer := path.JSONPointer()
+	switch field.Kind {
+	case Removed:
+		if field.JSONEmpty {
+			if field.Redacted {
+				return nil, fmt.Errorf("json patch: %s: cannot patch redacted value", pointer)
+			}
+			return append(ops, PatchOperation{Op: "replace", Path: pointer, Value: emptyValue(field)}), nil
+		}
+		return append(ops, PatchOperation{Op: "remove", Path: pointer}), nil
+	case Moved, Unchanged, Failed:
+		// Moves are done by the list that contains the item, and failed values have nothing to set.
+		return ops, nil
+	}
+
+	if field.Redacted {
+		return nil, fmt.Errorf("json patch: %s: cannot patch redacted value", pointer)
+	}
+	if field.Kind == Added {
+		return append(ops, PatchOperation{Op: "add", Path: pointer, Value: field.After}), nil
+	}
+	if len(field.Changes) == 0 {
+		return append(ops, PatchOperation{Op: "replace", Path: pointer, Value: field.After}), nil
+	}
+
+	// The value is a list if its changes are keyed by SliceIndex.
+	if isSliceChanges(field.Changes) {
+		steps, modified := planSlice(field.Changes)
+		for _, step := range steps {
+			switch step.op {
+			case stepRemove:
+				ops = append(ops, PatchOperation{Op: "remove", Path: appendPath(path, step.index).JSONPointer()})
+			case stepAdd:
+				var err error
+				ops, err = appendPatch(ops, appendPath(path, step.index), step.field)
+				if err != nil {
+					return nil, err
+				}
+			case stepMove:
+				ops = append(ops, PatchOperation{
+					Op:   "move",
+					From: appendPath(path, step.from).JSONPointer(),
+					Path: appendPath(path, step.index).JSONPointer(),
+				})
+			}
+		}
+		for _, child := range modified {
+			var err error
+			ops, err = appendPatch(ops, appendPath(path, child.Key.(SliceIndex).After), child)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return ops, nil
+	}
+
+	for _, child := range sortedFields(field.Changes) {
+		var err error
+		switch child.JSONName {
+		case "-":
+			// The field is not in the JSON encoding, so there's nothing to patch.
+		case "":
+			ops, err = appendPatch(ops, appendPath(path, child.Key), child)
+		default:
+			ops, err = appendPatch(ops, appendPath(path, child.JSONName), child)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ops, nil
+}
+
+// emptyValue returns the empty value that a removed field with JSONEmpty has in the JSON encoding.
+func emptyValue(field *ChangeField) any {
+	if field.AfterNil != NotNil || field.Before == nil {
+		return nil
+	}
+	t := reflect.TypeOf(field.Before)
+	switch t.Kind() {
+	case reflect.Slice:
+		return reflect.MakeSlice(t, 0, 0).Interface()
+	case reflect.Map:
+		return reflect.MakeMap(t).Interface()
+	}
+	return reflect.Zero(t).Interface()
+}
+
+// appendPath returns a copy of the path with the given element added.
+func appendPath(path Path, element any) Path {
+	return append(path[:len(path):len(path)], element)
+}
+
+// isSliceChanges returns true if the given changes are of a list.
+func isSliceChanges(changes ChangeMap[any]) bool {
+	for k := range changes {
+		_, ok := k.(SliceIndex)
+		return ok
+	}
+	return false
+}
+
+// sliceStepOp is the operation of a sliceStep.
+type sliceStepOp int
+
+const (
+	stepRemove sliceStepOp = iota
+	stepAdd
+	stepMove
+)
+
+// sliceStep is an operation that changes the structure of a list. Remove and add apply to index, move takes the item
+// at from and puts it at index.
+type sliceStep struct {
+	op    sliceStepOp
+	index int
+	from  int
+	field *ChangeField
+}
+
+// planSlice returns the steps that turn the before list into the after list when applied in order: removed items are
+// removed from the highest index, then new and moved items are put in their place from the lowest index. Items that
+// are modified are returned separately, sorted by their after index, their changes apply once all steps are done.
+//
+// Items that are not in the changes are unchanged, and they keep their order relative to each other. Only the part
+// of the list up to the highest index in the changes is looked at, the rest of the list is the same on both sides.
+func planSlice(changes ChangeMap[any]) (steps []sliceStep, modified []*ChangeField) {
+	removed := make(map[int]bool)
+	added := make(map[int]*ChangeField)
+	pairedBefore := make(map[int]bool)
+	pairedAfter := make(map[int]int)
+	n, m := 0, 0
+	for _, field := range changes {
+		index, ok := field.Key.(SliceIndex)
+		if ok == false {
+			continue
+		}
+		switch {
+		case index.After < 0:
+			removed[index.Before] = true
+		case index.Before < 0:
+			added[index.After] = field
+		default:
+			pairedBefore[index.Before] = true
+			pairedAfter[index.After] = index.Before
+			if field.Kind != Moved && field.Kind != Unchanged {
+				modified = append(modified, field)
+			}
+		}
+		n = max(n, index.Before+1)
+		m = max(m, index.After+1)
+	}
+	sort.Slice(modified, func(i int, j int) bool {
+		return modified[i].Key.(SliceIndex).After < modified[j].Key.(SliceIndex).After
+	})
+
+	// Unchanged items before index n are matched with unchanged items before index m in order. Extend the shorter
+	// side with unchanged items so that both sides have the same number of them.
+	var unchanged []int
+	for i := 0; i < n; i++ {
+		if removed[i] == false && pairedBefore[i] == false {
+			unchanged = append(unchanged, i)
+		}
+	}
+	unchangedAfter := 0
+	for j := 0; j < m; j++ {
+		if added[j] == nil {
+			if _, ok := pairedAfter[j]; ok == false {
+				unchangedAfter++
+			}
+		}
+	}
+	for ; len(unchanged) < unchangedAfter; n++ {
+		unchanged = append(unchanged, n)
+	}
+	m += len(unchanged) - unchangedAfter
+
+	// The list being worked on holds the before index of each item, new items are -1.
+	var working []int
+	for i := n - 1; i >= 0; i-- {
+		if removed[i] {
+			steps = append(steps, sliceStep{op: stepRemove, index: i})
+		}
+	}
+	for i := 0; i < n; i++ {
+		if removed[i] == false {
+			working = append(working, i)
+		}
+	}
+
+	for j := 0; j < m; j++ {
+		if field, ok := added[j]; ok {
+			steps = append(steps, sliceStep{op: stepAdd, index: j, field: field})
+			working = append(working[:j], append([]int{-1}, working[j:]...)...)
+			continue
+		}
+
+		want, ok := pairedAfter[j]
+		if ok == false {
+			want, unchanged = unchanged[0], unchanged[1:]
+		}
+		if working[j] == want {
+			continue
+		}
+		for from := j + 1; from < len(working); from++ {
+			if working[from] == want {
+				steps = append(steps, sliceStep{op: stepMove, index: j, from: from})
+				copy(working[j+1:from+1], working[j:from])
+				working[j] = want
+				break
+			}
+		}
+	}
+
+	return steps, modified
+}
@@ -0,0 +1,233 @@
+package differ
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestJSONPatch(t *testing.T) {
+	type line struct {
+		SKU string `json:"sku" differ:"key"`
+		Qty int    `json:"qty"`
+	}
+	type order struct {
+		Status string            `json:"status"`
+		Tags   []string          `json:"tags"`
+		Lines  []line            `json:"lines"`
+		Meta   map[string]string `json:"meta"`
+		Note   *string           `json:"note"`
+		Ref    string            `json:"ref" differ:"omitempty"`
+		Labels []string          `json:"labels,omitempty" differ:"omitempty"`
+		Owner  *line             `json:"owner" differ:"omitempty"`
+		secret string
+	}
+	note := "fragile"
+
+	type testRow struct {
+		name   string
+		before any
+		after  any
+		expect []PatchOperation
+	}
+
+	runRows := func(t *testing.T, rows []*testRow) {
+		for _, r := range rows {
+			t.Run(r.name, func(t *testing.T) {
+				_, changes, err := Diff("doc", r.before, r.after)
+				assert.Nil(t, err)
+
+				ops, err := JSONPatch(changes)
+				assert.Nil(t, err)
+				if r.expect != nil {
+					assert.Equal(t, r.expect, ops)
+				}
+
+				// Applying the patch to the JSON of before must result in the JSON of after.
+				assert.Equal(t, decodeJSON(t, r.after), applyTestPatch(t, decodeJSON(t, r.before), ops))
+			})
+		}
+	}
+
+	runRows(t, []*testRow{
+		{
+			name:   "primitive",
+			before: 1,
+			after:  2,
+			expect: []PatchOperation{{Op: "replace", Path: "", Value: 2}},
+		},
+		{
+			name:   "struct fields",
+			before: order{Status: "new", Meta: map[string]string{"a": "1", "b": "2"}},
+			after:  order{Status: "paid", Meta: map[string]string{"a": "1", "c/d": "3"}, Note: &note},
+			expect: []PatchOperation{
+				{Op: "remove", Path: "/meta/b"},
+				{Op: "add", Path: "/meta/c~1d", Value: "3"},
+				{Op: "replace", Path: "/note", Value: "fragile"},
+				{Op: "replace", Path: "/status", Value: "paid"},
+			},
+		},
+		{
+			name:   "field set to nil",
+			before: order{Note: &note},
+			after:  order{},
+			expect: []PatchOperation{{Op: "replace", Path: "/note", Value: nil}},
+		},
+		{
+			name:   "list inserts and deletes",
+			before: order{Tags: []string{"a", "b", "c", "d", "e"}},
+			after:  order{Tags: []string{"x", "a", "c", "y", "d", "z"}},
+		},
+		{
+			name:   "list item replaced",
+			before: []string{"a", "b", "c"},
+			after:  []string{"a", "x", "c"},
+			expect: []PatchOperation{{Op: "replace", Path: "/1", Value: "x"}},
+		},
+		{
+			name:   "list items moved by key",
+			before: order{Lines: []line{{"a", 1}, {"b", 1}, {"c", 1}, {"d", 1}}},
+			after:  order{Lines: []line{{"d", 1}, {"b", 2}, {"a", 1}, {"e", 1}}},
+		},
+		{
+			name:   "list item moved to the end",
+			before: []line{{"a", 1}, {"b", 1}, {"c", 1}},
+			after:  []line{{"b", 1}, {"c", 1}, {"a", 1}},
+			expect: []PatchOperation{
+				{Op: "move", From: "/1", Path: "/0"},
+				{Op: "move", From: "/2", Path: "/1"},
+			},
+		},
+		{
+			name:   "field named by the differ tag",
+			before: testTagged{Email: "a", Secret: "a", Nickname: "a"},
+			after:  testTagged{Email: "b", Secret: "b", Nickname: "b"},
+			expect: []PatchOperation{
+				{Op: "replace", Path: "/email", Value: "b"},
+				{Op: "replace", Path: "/Nickname", Value: "b"},
+			},
+		},
+		{
+			name:   "differ omitempty fields set to empty",
+			before: order{Ref: "a", Labels: []string{"x"}, Owner: &line{SKU: "a"}},
+			after:  order{},
+			expect: []PatchOperation{
+				{Op: "remove", Path: "/labels"},
+				{Op: "replace", Path: "/owner", Value: nil},
+				{Op: "replace", Path: "/ref", Value: ""},
+			},
+		},
+		{
+			name:   "differ omitempty fields set from empty",
+			before: order{},
+			after:  order{Ref: "a", Labels: []string{"x"}, Owner: &line{SKU: "a"}},
+		},
+		{
+			name:   "unexported field",
+			before: order{Status: "new", secret: "s1"},
+			after:  order{Status: "paid", secret: "s2"},
+			expect: []PatchOperation{{Op: "replace", Path: "/status", Value: "paid"}},
+		},
+	})
+}
+
+func TestJSONPatch_Redacted(t *testing.T) {
+	// A redacted value would be replaced with the mask.
+	_, changes, err := Diff("account", testAccount{Password: "a"}, testAccount{Password: "b"})
+	assert.Nil(t, err)
+	ops, err := JSONPatch(changes)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "/password")
+	assert.Nil(t, ops)
+
+	_, changes, err = Diff("tokens", []string{"a"}, []string{"a", "b"}, WithRedactedPaths("*"))
+	assert.Nil(t, err)
+	_, err = JSONPatch(changes)
+	assert.NotNil(t, err)
+
+	// Redacted values can still be removed.
+	_, changes, err = Diff("tokens", []string{"a", "b"}, []string{"a"}, WithRedactedPaths("*"))
+	assert.Nil(t, err)
+	ops, err = JSONPatch(changes)
+	assert.Nil(t, err)
+	assert.Equal(t, []PatchOperation{{Op: "remove", Path: "/1"}}, ops)
+}
+
+func TestPatchOperation_MarshalJSON(t *testing.T) {
+	b, err := json.Marshal([]PatchOperation{
+		{Op: "replace", Path: "/a", Value: nil},
+		{Op: "remove", Path: "/b"},
+		{Op: "move", From: "/c", Path: "/d"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, `[{"op":"replace","path":"/a","value":null},{"op":"remove","path":"/b"},`+
+		`{"op":"move","path":"/d","from":"/c"}]`, string(b))
+}
+
+func decodeJSON(t *testing.T, value any) any {
+	b, err := json.Marshal(value)
+	assert.Nil(t, err)
+	var decoded any
+	assert.Nil(t, json.Unmarshal(b, &decoded))
+	return decoded
+}
+
+// applyTestPatch is a minimal JSON Patch implementation, enough to check the operations returned by JSONPatch.
+func applyTestPatch(t *testing.T, doc any, ops []PatchOperation) any {
+	var apply func(node any, tokens []string, op PatchOperation, value any) (any, any)
+	apply = func(node any, tokens []string, op PatchOperation, value any) (any, any) {
+		if len(tokens) == 0 {
+			if op.Op == "remove" {
+				return nil, node
+			}
+			return value, node
+		}
+		token := strings.NewReplacer("~1", "/", "~0", "~").Replace(tokens[0])
+		switch n := node.(type) {
+		case map[string]any:
+			if len(tokens) == 1 && op.Op == "remove" {
+				old := n[token]
+				delete(n, token)
+				return n, old
+			}
+			var old any
+			n[token], old = apply(n[token], tokens[1:], op, value)
+			return n, old
+		case []any:
+			i, err := strconv.Atoi(token)
+			assert.Nil(t, err)
+			if len(tokens) == 1 && op.Op == "remove" {
+				old := n[i]
+				return append(n[:i], n[i+1:]...), old
+			}
+			if len(tokens) == 1 && op.Op == "add" {
+				return append(n[:i], append([]any{value}, n[i:]...)...), nil
+			}
+			var old any
+			n[i], old = apply(n[i], tokens[1:], op, value)
+			return n, old
+		}
+		t.Fatalf("cannot apply %v to %v", op, node)
+		return nil, nil
+	}
+	split := func(pointer string) []string {
+		if pointer == "" {
+			return nil
+		}
+		return strings.Split(pointer[1:], "/")
+	}
+
+	for _, op := range ops {
+		value := decodeJSON(t, op.Value)
+		if op.Op == "move" {
+			var moved any
+			doc, moved = apply(doc, split(op.From), PatchOperation{Op: "remove"}, nil)
+			doc, _ = apply(doc, split(op.Path), PatchOperation{Op: "add"}, moved)
+			continue
+		}
+		doc, _ = apply(doc, split(op.Path), op, value)
+	}
+	return doc
+}
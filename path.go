@@ -0,0 +1,123 @@
+package differ
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Path is the location of a change, starting with the key given to Diff. Each element is either a struct field name,
+// a map key, or an int index of a list item.
+type Path []any
+
+// String returns the path in dotted notation, with list indexes and non-string map keys in brackets, for example
+// order.items[2].price. String keys that can't be written after a dot are quoted in brackets, like tags["a.b"].
+func (p Path) String() string {
+	var sb strings.Builder
+	for i, element := range p {
+		name, ok := element.(string)
+		if ok == false {
+			sb.WriteString("[" + fmt.Sprint(element) + "]")
+			continue
+		}
+		if name == "" || strings.ContainsAny(name, ".[]\"' \t\n") {
+			sb.WriteString("[" + strconv.Quote(name) + "]")
+			continue
+		}
+		if i > 0 {
+			sb.WriteString(".")
+		}
+		sb.WriteString(name)
+	}
+	return sb.String()
+}
+
+// JSONPointer returns the path as an RFC 6901 JSON Pointer, for example /order/items/2/price. Non-string elements are
+// written with fmt.
+func (p Path) JSONPointer() string {
+	var sb strings.Builder
+	for _, element := range p {
+		sb.WriteString("/")
+		sb.WriteString(pointerEscaper.Replace(fmt.Sprint(element)))
+	}
+	return sb.String()
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// pathElement returns the path element of the given change. List items are keyed by SliceIndex, their path element is
+// the index in the after list, or the index in the before list if the item is removed.
+func pathElement(field *ChangeField) any {
+	index, ok := field.Key.(SliceIndex)
+	if ok == false {
+		return field.Key
+	}
+	if index.After < 0 {
+		return index.Before
+	}
+	return index.After
+}
+
+// walkLeaves calls fn for the given change if it has no nested changes, otherwise it walks the nested changes in
+// sorted order.
+func walkLeaves(path Path, field *ChangeField, fn func(path Path, field *ChangeField)) {
+	path = append(path[:len(path):len(path)], pathElement(field))
+	if len(field.Changes) == 0 {
+		fn(path, field)
+		return
+	}
+	for _, child := range sortedFields(field.Changes) {
+		walkLeaves(path, child, fn)
+	}
+}
+
+// sortedFields returns the changes sorted by key, so that the output of renderers is stable. List items are sorted by
+// their position, with removed items before new items at the same position. Other keys are sorted by their type, then
+// by their value.
+func sortedFields[K comparable](changes ChangeMap[K]) []*ChangeField {
+	fields := make([]*ChangeField, 0, len(changes))
+	for _, field := range changes {
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i int, j int) bool {
+		return lessKey(fields[i].Key, fields[j].Key)
+	})
+	return fields
+}
+
+func lessKey(a any, b any) bool {
+	if ia, ok := a.(SliceIndex); ok {
+		if ib, ok := b.(SliceIndex); ok {
+			pa, pb := ia.After, ib.After
+			if pa < 0 {
+				pa = ia.Before
+			}
+			if pb < 0 {
+				pb = ib.Before
+			}
+			if pa != pb {
+				return pa < pb
+			}
+			return ia.After < ib.After
+		}
+	}
+
+	ta, tb := fmt.Sprintf("%T", a), fmt.Sprintf("%T", b)
+	if ta != tb {
+		return ta < tb
+	}
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch va.Kind() {
+	case reflect.String:
+		return va.String() < vb.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return va.Int() < vb.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return va.Uint() < vb.Uint()
+	case reflect.Float32, reflect.Float64:
+		return va.Float() < vb.Float()
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
@@ -0,0 +1,105 @@
+package differ
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pathPattern is a path in dotted notation that can have wildcards, parsed by parsePathPattern.
+type pathPattern []patternElement
+
+// patternElement is a single element of a pathPattern.
+type patternElement struct {
+	// name is the struct field name, map key or list index that the element matches, written as in Path.String.
+	name string
+	// wildcard is "*" when the element matches any single element, or "**" when it matches any number of elements,
+	// including none. Wildcards are only recognised when they're not quoted.
+	wildcard string
+}
+
+// literalPattern returns a pattern that only matches the given path.
+func literalPattern(path Path) pathPattern {
+	pattern := make(pathPattern, len(path))
+	for i, element := range path {
+		name, ok := element.(string)
+		if ok == false {
+			name = fmt.Sprint(element)
+		}
+		pattern[i] = patternElement{name: name}
+	}
+	return pattern
+}
+
+// parsePathPattern parses a path written in dotted notation, like Path.String writes it. An element that is "*", or a
+// list index that is [*], matches any struct field, map key or list index at that position. An element that is "**"
+// matches any number of elements, so "**.resourceVersion" matches resourceVersion at any depth.
+func parsePathPattern(pattern string) (pathPattern, error) {
+	var elements pathPattern
+	add := func(name string, quoted bool) {
+		if quoted == false && (name == "*" || name == "**") {
+			elements = append(elements, patternElement{wildcard: name})
+			return
+		}
+		elements = append(elements, patternElement{name: name})
+	}
+
+	rest := pattern
+	for len(rest) > 0 {
+		switch {
+		case rest[0] == '.':
+			rest = rest[1:]
+		case rest[0] == '[' && strings.HasPrefix(rest, `["`):
+			quoted, err := strconv.QuotedPrefix(rest[1:])
+			if err != nil || strings.HasPrefix(rest[1+len(quoted):], "]") == false {
+				return nil, fmt.Errorf("diff: invalid path pattern %q: unterminated quoted key", pattern)
+			}
+			name, _ := strconv.Unquote(quoted)
+			add(name, true)
+			rest = rest[len(quoted)+2:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("diff: invalid path pattern %q: missing ]", pattern)
+			}
+			add(rest[1:end], false)
+			rest = rest[end+1:]
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			add(rest[:end], false)
+			rest = rest[end:]
+		}
+	}
+	return elements, nil
+}
+
+// match returns true if the path matches the pattern.
+func (p pathPattern) match(path Path) bool {
+	if len(p) == 0 {
+		return len(path) == 0
+	}
+
+	switch p[0].wildcard {
+	case "**":
+		for i := 0; i <= len(path); i++ {
+			if p[1:].match(path[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(path) > 0 && p[1:].match(path[1:])
+	}
+
+	if len(path) == 0 {
+		return false
+	}
+	name, ok := path[0].(string)
+	if ok == false {
+		name = fmt.Sprint(path[0])
+	}
+	return name == p[0].name && p[1:].match(path[1:])
+}
@@ -0,0 +1,48 @@
+package differ
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPathPattern(t *testing.T) {
+	type testRow struct {
+		name    string
+		pattern string
+		path    Path
+
+		expectError bool
+		expectMatch bool
+	}
+
+	runRows := func(t *testing.T, rows []*testRow) {
+		for _, r := range rows {
+			t.Run(r.name, func(t *testing.T) {
+				pattern, err := parsePathPattern(r.pattern)
+				if r.expectError {
+					assert.NotNil(t, err)
+					return
+				}
+				assert.Nil(t, err)
+				assert.Equal(t, r.expectMatch, pattern.match(r.path))
+			})
+		}
+	}
+
+	runRows(t, []*testRow{
+		{name: "exact", pattern: "metadata.resourceVersion", path: Path{"metadata", "resourceVersion"}, expectMatch: true},
+		{name: "exact mismatch", pattern: "metadata.name", path: Path{"metadata", "resourceVersion"}, expectMatch: false},
+		{name: "prefix only", pattern: "metadata", path: Path{"metadata", "name"}, expectMatch: false},
+		{name: "index", pattern: "items[2].etag", path: Path{"items", 2, "etag"}, expectMatch: true},
+		{name: "any index", pattern: "items[*].etag", path: Path{"items", 7, "etag"}, expectMatch: true},
+		{name: "any field", pattern: "*.updatedAt", path: Path{"order", "updatedAt"}, expectMatch: true},
+		{name: "any field is 1 element", pattern: "*.updatedAt", path: Path{"a", "b", "updatedAt"}, expectMatch: false},
+		{name: "any depth", pattern: "**.resourceVersion", path: Path{"a", 1, "metadata", "resourceVersion"}, expectMatch: true},
+		{name: "any depth includes none", pattern: "**.resourceVersion", path: Path{"resourceVersion"}, expectMatch: true},
+		{name: "any depth in the middle", pattern: "spec.**.image", path: Path{"spec", "containers", 0, "image"}, expectMatch: true},
+		{name: "quoted key", pattern: `tags["a.b"]`, path: Path{"tags", "a.b"}, expectMatch: true},
+		{name: "quoted star is not a wildcard", pattern: `tags["*"]`, path: Path{"tags", "a"}, expectMatch: false},
+		{name: "unterminated bracket", pattern: "items[1", expectError: true},
+		{name: "unterminated quote", pattern: `tags["a]`, expectError: true},
+	})
+}
@@ -0,0 +1,47 @@
+package differ
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// RedactedMask replaces the values of redacted fields, see WithRedactedPaths.
+const RedactedMask = "[REDACTED]"
+
+// redact returns a copy of the change with the given before and after values masked, and without the changes within
+// it. Sides that don't exist, like the before of an added item, are left nil.
+func (o *options) redact(change *ChangeField, before any, after any) *ChangeField {
+	redacted := *change
+	redacted.Redacted = true
+	redacted.Changes = nil
+	redacted.Before = nil
+	redacted.After = nil
+	if change.Kind != Added {
+		redacted.Before = o.mask(before)
+	}
+	if change.Kind != Removed {
+		redacted.After = o.mask(after)
+	}
+	return &redacted
+}
+
+// mask returns the mask of a redacted value, nil stays nil so that setting a value from nil is still visible.
+func (o *options) mask(value any) any {
+	if value == nil {
+		return nil
+	}
+	if o.redactionSalt == nil {
+		return RedactedMask
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		b = []byte(fmt.Sprintf("%#v", value))
+	}
+	mac := hmac.New(sha256.New, o.redactionSalt)
+	mac.Write(b)
+	return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,216 @@
+package differ
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+type testAccount struct {
+	Email    string            `json:"email"`
+	Password string            `json:"password" differ:"redact"`
+	Tokens   []string          `json:"tokens"`
+	Profile  map[string]string `json:"profile"`
+}
+
+func TestRedact(t *testing.T) {
+	type testRow struct {
+		name   string
+		before any
+		after  any
+		opts   []Option
+
+		expectHasChanges bool
+		expectChanges    *ChangeField
+	}
+
+	runRows := func(t *testing.T, rows []*testRow) {
+		for _, r := range rows {
+			t.Run(r.name, func(t *testing.T) {
+				hasChanges, changes, err := Diff("account", r.before, r.after, r.opts...)
+				assert.Nil(t, err)
+				assert.Equal(t, r.expectHasChanges, hasChanges)
+				assert.Equal(t, r.expectChanges, changes["account"])
+			})
+		}
+	}
+
+	runRows(t, []*testRow{
+		{
+			name:             "tag",
+			before:           testAccount{Email: "a@example.com", Password: "hunter2"},
+			after:            testAccount{Email: "a@example.com", Password: "hunter3"},
+			expectHasChanges: true,
+			expectChanges: &ChangeField{
+				Key:       "account",
+				Kind:      Modified,
+				IsChanged: true,
+				Changes: ChangeMap[any]{
+					"password": {
+						Key:       "password",
+						Kind:      Modified,
+						IsChanged: true,
+						Redacted:  true,
+						Before:    RedactedMask,
+						After:     RedactedMask,
+					},
+				},
+			},
+		},
+		{
+			name:             "tag unchanged",
+			before:           testAccount{Password: "hunter2"},
+			after:            testAccount{Password: "hunter2"},
+			expectHasChanges: false,
+		},
+		{
+			name:             "tag set from empty",
+			before:           &testAccount{},
+			after:            &testAccount{Password: "hunter2"},
+			expectHasChanges: true,
+			expectChanges: &ChangeField{
+				Key:       "account",
+				Kind:      Modified,
+				IsChanged: true,
+				Changes: ChangeMap[any]{
+					"password": {
+						Key:       "password",
+						Kind:      Modified,
+						IsChanged: true,
+						Redacted:  true,
+						Before:    RedactedMask,
+						After:     RedactedMask,
+					},
+				},
+			},
+		},
+		{
+			name:             "paths",
+			before:           testAccount{Tokens: []string{"a", "b"}, Profile: map[string]string{"ssn": "1", "city": "X"}},
+			after:            testAccount{Tokens: []string{"b", "c"}, Profile: map[string]string{"ssn": "2", "city": "Y"}},
+			opts:             []Option{WithRedactedPaths("tokens[*]", "profile.ssn")},
+			expectHasChanges: true,
+			expectChanges: &ChangeField{
+				Key:       "account",
+				Kind:      Modified,
+				IsChanged: true,
+				Changes: ChangeMap[any]{
+					"tokens": {
+						Key:       "tokens",
+						Kind:      Modified,
+						IsChanged: true,
+						Changes: ChangeMap[any]{
+							SliceIndex{0, -1}: {
+								Key:       SliceIndex{0, -1},
+								Kind:      Removed,
+								IsChanged: true,
+								Redacted:  true,
+								Before:    RedactedMask,
+							},
+							SliceIndex{-1, 1}: {
+								Key:       SliceIndex{-1, 1},
+								Kind:      Added,
+								IsNew:     true,
+								IsChanged: true,
+								Redacted:  true,
+								After:     RedactedMask,
+							},
+						},
+					},
+					"profile": {
+						Key:       "profile",
+						Kind:      Modified,
+						IsChanged: true,
+						Changes: ChangeMap[any]{
+							"ssn": {
+								Key:       "ssn",
+								Kind:      Modified,
+								IsChanged: true,
+								Redacted:  true,
+								Before:    RedactedMask,
+								After:     RedactedMask,
+							},
+							"city": {Key: "city", Kind: Modified, IsChanged: true, Before: "X", After: "Y"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:             "whole struct",
+			before:           map[string]testAccount{"a": {Email: "a@example.com"}},
+			after:            map[string]testAccount{"a": {Email: "b@example.com"}},
+			opts:             []Option{WithRedactedPaths("*")},
+			expectHasChanges: true,
+			expectChanges: &ChangeField{
+				Key:       "account",
+				Kind:      Modified,
+				IsChanged: true,
+				Changes: ChangeMap[any]{
+					"a": {
+						Key:       "a",
+						Kind:      Modified,
+						IsChanged: true,
+						Redacted:  true,
+						Before:    RedactedMask,
+						After:     RedactedMask,
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestRedact_CollectErrors(t *testing.T) {
+	type inner struct {
+		C chan int
+	}
+	type holder struct {
+		Secret inner `differ:"redact"`
+	}
+	type untagged struct {
+		Secret inner
+	}
+
+	// A redacted field that can't be diffed is recorded as failed, like a field redacted by path.
+	c := make(chan int)
+	hasChanges, changes, err := Diff("x", holder{inner{c}}, holder{inner{c}}, WithCollectErrors())
+	assert.True(t, errors.Is(err, ErrUnsupportedKind))
+	assert.Contains(t, err.Error(), "x.Secret.C")
+	assert.True(t, hasChanges)
+	assert.Equal(t, Failed, changes["x"].Changes["Secret"].Kind)
+	assert.False(t, changes["x"].Changes["Secret"].Redacted)
+
+	_, byPath, pathErr := Diff("x", untagged{inner{c}}, untagged{inner{c}}, WithCollectErrors(), WithRedactedPaths("Secret"))
+	assert.Equal(t, pathErr.Error(), err.Error())
+	assert.Equal(t, byPath, changes)
+}
+
+func TestRedact_Hash(t *testing.T) {
+	salt := WithRedactionHash([]byte("salt"))
+	_, changes, err := Diff("account", testAccount{Password: "a"}, testAccount{Password: "b"}, salt)
+	assert.Nil(t, err)
+	first := changes["account"].Changes["password"]
+	assert.True(t, strings.HasPrefix(first.Before.(string), "hmac-sha256:"))
+	assert.NotEqual(t, first.Before, first.After)
+
+	// The same value gives the same hash.
+	_, changes, err = Diff("account", testAccount{Password: "b"}, testAccount{Password: "c"}, salt)
+	assert.Nil(t, err)
+	assert.Equal(t, first.After, changes["account"].Changes["password"].Before)
+
+	// A different salt gives a different hash.
+	_, changes, err = Diff("account", testAccount{Password: "a"}, testAccount{Password: "b"}, WithRedactionHash([]byte("other")))
+	assert.Nil(t, err)
+	assert.NotEqual(t, first.Before, changes["account"].Changes["password"].Before)
+}
+
+func TestRedact_Apply(t *testing.T) {
+	_, changes, err := Diff("account", testAccount{Password: "a"}, testAccount{Password: "b"})
+	assert.Nil(t, err)
+
+	target := testAccount{Password: "a"}
+	assert.NotNil(t, Apply(&target, changes))
+	assert.Equal(t, "a", target.Password)
+}
@@ -0,0 +1,127 @@
+package differ
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// QuoteStyle decides how Render writes Before and After values.
+type QuoteStyle int
+
+const (
+	// QuoteGo quotes strings with strconv.Quote, other values are written with fmt.
+	QuoteGo QuoteStyle = iota
+	// QuoteNone writes every value with fmt, strings are not quoted.
+	QuoteNone
+	// QuoteJSON writes every value with json.Marshal.
+	QuoteJSON
+)
+
+// RenderOptions configures Render. The zero value renders plain text without width limit, with strings quoted.
+type RenderOptions struct {
+	// Color adds ANSI colours to the lines: green for added, red for removed, yellow for modified, and cyan for moved.
+	Color bool
+	// Width is the maximum width of a line, longer lines are cut and end with "…". Zero means there's no limit.
+	Width int
+	// Quote decides how values are written.
+	Quote QuoteStyle
+	// Formatters convert values to display strings, which are written as they are, without quotes.
+	Formatters []Formatter
+}
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+)
+
+// Render returns the changes as human-readable text, 1 line per changed value, sorted by path. Each line starts with
+// a marker that is indented the same way for every kind of change:
+//
+//	  order.items[2].price: 10.5 → 12
+//	+ tags[3]: "vip"
+//	- tags[1]: "new"
+//	~ roles[0]: moved from [2]
+func Render[K comparable](changes ChangeMap[K], opts RenderOptions) string {
+	var sb strings.Builder
+	formatters := newFormatters(opts.Formatters)
+	for _, field := range sortedFields(changes) {
+		walkLeaves(nil, field, func(path Path, field *ChangeField) {
+			line, color := renderLine(path, field, opts, formatters)
+			line = truncate(line, opts.Width)
+			if opts.Color && color != "" {
+				line = color + line + ansiReset
+			}
+			sb.WriteString(line)
+			sb.WriteString("\n")
+		})
+	}
+	return sb.String()
+}
+
+// renderLine returns the line for the given change, and the colour it should be written with.
+func renderLine(path Path, field *ChangeField, opts RenderOptions, formatters formatters) (line string, color string) {
+	value := func(value any) string {
+		if formatted, ok := formatters.format(path, value); ok {
+			return formatted
+		}
+		return renderValue(value, opts)
+	}
+
+	switch field.Kind {
+	case Added:
+		return "+ " + path.String() + ": " + value(field.After), ansiGreen
+	case Removed:
+		return "- " + path.String() + ": " + value(field.Before), ansiRed
+	case Moved:
+		index := field.Key.(SliceIndex)
+		return "~ " + path.String() + ": moved from [" + strconv.Itoa(index.Before) + "]", ansiCyan
+	case Failed:
+		return "! " + path.String() + ": " + field.Err.Error(), ansiRed
+	case Unchanged:
+		if field.Cycle {
+			return "  " + path.String() + ": <cycle>", ""
+		}
+		return "  " + path.String() + ": " + value(field.After), ""
+	}
+	return "  " + path.String() + ": " + value(field.Before) + " → " + value(field.After), ansiYellow
+}
+
+// renderValue writes the value in the given quote style.
+func renderValue(value any, opts RenderOptions) string {
+	switch opts.Quote {
+	case QuoteJSON:
+		b, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(b)
+	case QuoteNone:
+		if value == nil {
+			return "nil"
+		}
+		return fmt.Sprint(value)
+	}
+
+	if value == nil {
+		return "nil"
+	}
+	if s, ok := value.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(value)
+}
+
+// truncate cuts the line so that it's at most width characters long.
+func truncate(line string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(line) <= width {
+		return line
+	}
+	runes := []rune(line)
+	return string(runes[:width-1]) + "…"
+}
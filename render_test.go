@@ -0,0 +1,105 @@
+package differ
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	type item struct {
+		Name  string  `json:"name"`
+		Price float64 `json:"price"`
+	}
+	type order struct {
+		Items []item           `json:"items"`
+		Tags  []string         `json:"tags"`
+		Notes map[string]any   `json:"notes"`
+		Roles []testRenderRole `json:"roles"`
+	}
+
+	before := order{
+		Items: []item{{"a", 1}, {"b", 2}, {"c", 10.5}},
+		Tags:  []string{"new", "big", "paid"},
+		Notes: map[string]any{"a.b": 1, "gift": true},
+		Roles: []testRenderRole{{"x"}, {"y"}},
+	}
+	after := order{
+		Items: []item{{"a", 1}, {"b", 2}, {"c", 12.25}},
+		Tags:  []string{"big", "paid", "vip"},
+		Notes: map[string]any{"a.b": 2, "gift": true},
+		Roles: []testRenderRole{{"y"}, {"x"}},
+	}
+	_, changes, err := Diff("order", before, after)
+	assert.Nil(t, err)
+
+	type testRow struct {
+		name   string
+		opts   RenderOptions
+		expect string
+	}
+
+	runRows := func(t *testing.T, rows []*testRow) {
+		for _, r := range rows {
+			t.Run(r.name, func(t *testing.T) {
+				assert.Equal(t, r.expect, Render(changes, r.opts))
+			})
+		}
+	}
+
+	runRows(t, []*testRow{
+		{
+			name: "default",
+			opts: RenderOptions{},
+			expect: "" +
+				"  order.items[2].price: 10.5 → 12.25\n" +
+				"  order.notes[\"a.b\"]: 1 → 2\n" +
+				"~ order.roles[1]: moved from [0]\n" +
+				"- order.tags[0]: \"new\"\n" +
+				"+ order.tags[2]: \"vip\"\n",
+		},
+		{
+			name: "no quote and width",
+			opts: RenderOptions{Quote: QuoteNone, Width: 20},
+			expect: "" +
+				"  order.items[2].pr…\n" +
+				"  order.notes[\"a.b\"…\n" +
+				"~ order.roles[1]: m…\n" +
+				"- order.tags[0]: new\n" +
+				"+ order.tags[2]: vip\n",
+		},
+		{
+			name: "color and json",
+			opts: RenderOptions{Color: true, Quote: QuoteJSON},
+			expect: "" +
+				ansiYellow + "  order.items[2].price: 10.5 → 12.25" + ansiReset + "\n" +
+				ansiYellow + "  order.notes[\"a.b\"]: 1 → 2" + ansiReset + "\n" +
+				ansiCyan + "~ order.roles[1]: moved from [0]" + ansiReset + "\n" +
+				ansiRed + "- order.tags[0]: \"new\"" + ansiReset + "\n" +
+				ansiGreen + "+ order.tags[2]: \"vip\"" + ansiReset + "\n",
+		},
+		{
+			name: "formatters",
+			opts: RenderOptions{Formatters: []Formatter{
+				{Path: "items[*].price", Format: func(value any) string {
+					return fmt.Sprintf("$%.2f", value)
+				}},
+				{Type: reflect.TypeOf(""), Format: func(value any) string {
+					return strings.ToUpper(value.(string))
+				}},
+			}},
+			expect: "" +
+				"  order.items[2].price: $10.50 → $12.25\n" +
+				"  order.notes[\"a.b\"]: 1 → 2\n" +
+				"~ order.roles[1]: moved from [0]\n" +
+				"- order.tags[0]: NEW\n" +
+				"+ order.tags[2]: VIP\n",
+		},
+	})
+}
+
+type testRenderRole struct {
+	ID string `differ:"key"`
+}